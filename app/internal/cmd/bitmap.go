@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func handleSetbit(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	offset, err := parseBitOffset(cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	var bit byte
+	switch cmd[3].BulkStrs() {
+	case "0":
+	case "1":
+		bit = 1
+	default:
+		return "", errors.ErrBitValue
+	}
+
+	prev, err := store.SetBit(cmd[1].BulkStrs(), offset, bit)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(prev)), nil
+}
+
+func handleGetbit(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	offset, err := parseBitOffset(cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	bit, err := store.GetBit(cmd[1].BulkStrs(), offset)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(bit)), nil
+}
+
+// BITCOUNT key [start end [BYTE | BIT]]
+func handleBitcount(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 || len(cmd) > 5 {
+		return "", errors.ErrInvalidCmd
+	}
+	// The start of the range can't be given without its end
+	if len(cmd) == 3 {
+		return "", errors.ErrSyntax
+	}
+
+	r, err := parseBitRange(cmd[2:])
+	if err != nil {
+		return "", err
+	}
+
+	cnt, err := store.BitCount(cmd[1].BulkStrs(), r)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(cnt), nil
+}
+
+// BITPOS key bit [start [end [BYTE | BIT]]]
+func handleBitpos(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 || len(cmd) > 6 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var bit byte
+	switch cmd[2].BulkStrs() {
+	case "0":
+	case "1":
+		bit = 1
+	default:
+		return "", errors.ErrBitposBit
+	}
+
+	r, err := parseBitRange(cmd[3:])
+	if err != nil {
+		return "", err
+	}
+
+	pos, err := store.BitPos(cmd[1].BulkStrs(), bit, r)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(pos), nil
+}
+
+// BITOP <AND | OR | XOR | NOT | DIFF> destkey key [key ...]
+func handleBitop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	srcs := toStrs(cmd[3:])
+
+	var op storage.BitOp
+	switch strings.ToUpper(cmd[1].BulkStrs()) {
+	case "AND":
+		op = storage.BitOpAnd
+	case "OR":
+		op = storage.BitOpOr
+	case "XOR":
+		op = storage.BitOpXor
+	case "NOT":
+		if len(srcs) != 1 {
+			return "", errors.ErrBitopNot
+		}
+		op = storage.BitOpNot
+	case "DIFF":
+		if len(srcs) < 2 {
+			return "", errors.ErrBitopDiff
+		}
+		op = storage.BitOpDiff
+	default:
+		return "", errors.ErrSyntax
+	}
+
+	n, err := store.BitOp(op, cmd[2].BulkStrs(), srcs...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// parseBitOffset parses the bit offset argument, which must fit in a string of the maximum length.
+func parseBitOffset(arg string) (int64, error) {
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 || offset > storage.MaxBitOffset {
+		return 0, errors.ErrBitOffset
+	}
+
+	return offset, nil
+}
+
+// parseBitRange parses the optional [start [end [BYTE | BIT]]] arguments of the bitmap commands.
+func parseBitRange(args []*protocol.RespVal) (storage.BitRange, error) {
+	var (
+		r   storage.BitRange
+		err error
+	)
+
+	if len(args) > 0 {
+		if r.Start, err = strconv.ParseInt(args[0].BulkStrs(), 10, 64); err != nil {
+			return r, errors.ErrNotANumericValue
+		}
+	}
+
+	if len(args) > 1 {
+		if r.End, err = strconv.ParseInt(args[1].BulkStrs(), 10, 64); err != nil {
+			return r, errors.ErrNotANumericValue
+		}
+		r.HasEnd = true
+	}
+
+	if len(args) > 2 {
+		switch strings.ToUpper(args[2].BulkStrs()) {
+		case "BYTE":
+		case "BIT":
+			r.IsBit = true
+		default:
+			return r, errors.ErrSyntax
+		}
+	}
+
+	return r, nil
+}
+
+// BITFIELD key [GET type offset | [OVERFLOW <WRAP | SAT | FAIL>] <SET type offset value | INCRBY type offset increment> ...]
+func handleBitfield(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return bitfield(cmd, store, false)
+}
+
+// BITFIELD_RO key [GET type offset ...]
+func handleBitfieldRo(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return bitfield(cmd, store, true)
+}
+
+func bitfield(cmd []*protocol.RespVal, store *storage.Mem, readOnly bool) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		ops      []storage.BitfieldOp
+		overflow = storage.OverflowWrap
+	)
+
+	// Parse all the subcommands before performing any of them
+	args := cmd[2:]
+	for len(args) > 0 {
+		sub := strings.ToUpper(args[0].BulkStrs())
+		if readOnly && sub != "GET" {
+			return "", errors.ErrBitfieldRO
+		}
+
+		op := storage.BitfieldOp{Overflow: overflow}
+		argc := 3
+		switch sub {
+		case "GET":
+			op.Kind = storage.BitfieldGet
+		case "SET":
+			op.Kind = storage.BitfieldSet
+			argc = 4
+		case "INCRBY":
+			op.Kind = storage.BitfieldIncrBy
+			argc = 4
+		case "OVERFLOW":
+			if len(args) < 2 {
+				return "", errors.ErrSyntax
+			}
+			switch strings.ToUpper(args[1].BulkStrs()) {
+			case "WRAP":
+				overflow = storage.OverflowWrap
+			case "SAT":
+				overflow = storage.OverflowSat
+			case "FAIL":
+				overflow = storage.OverflowFail
+			default:
+				return "", errors.ErrBitfieldOverflow
+			}
+			args = args[2:]
+			continue
+		default:
+			return "", errors.ErrSyntax
+		}
+
+		if len(args) < argc {
+			return "", errors.ErrSyntax
+		}
+
+		var err error
+		if op.Signed, op.Bits, err = parseBitfieldType(args[1].BulkStrs()); err != nil {
+			return "", err
+		}
+		if op.Offset, err = parseBitfieldOffset(args[2].BulkStrs(), op.Bits); err != nil {
+			return "", err
+		}
+		if argc == 4 {
+			if op.Value, err = strconv.ParseInt(args[3].BulkStrs(), 10, 64); err != nil {
+				return "", errors.ErrNotANumericValue
+			}
+		}
+
+		ops = append(ops, op)
+		args = args[argc:]
+	}
+
+	results, err := store.Bitfield(cmd[1].BulkStrs(), ops)
+	if err != nil {
+		return "", err
+	}
+
+	elems := make([]string, len(results))
+	for i, res := range results {
+		if res == nil {
+			elems[i] = protocol.ToNulls()
+		} else {
+			elems[i] = protocol.ToIntegers(res.(int64))
+		}
+	}
+
+	return protocol.ToArray(elems), nil
+}
+
+// parseBitfieldType parses the type of the field, such as "i16" or "u8". The signed integers are up
+// to 64 bits wide, and the unsigned ones up to 63 bits.
+func parseBitfieldType(arg string) (bool, int, error) {
+	if len(arg) < 2 {
+		return false, 0, errors.ErrBitfieldType
+	}
+
+	var signed bool
+	switch arg[0] {
+	case 'i', 'I':
+		signed = true
+	case 'u', 'U':
+	default:
+		return false, 0, errors.ErrBitfieldType
+	}
+
+	bits, err := strconv.Atoi(arg[1:])
+	if err != nil || bits < 1 || (signed && bits > 64) || (!signed && bits > 63) {
+		return false, 0, errors.ErrBitfieldType
+	}
+
+	return signed, bits, nil
+}
+
+// parseBitfieldOffset parses the bit offset of the field of the given width. The offset prefixed with
+// "#" is multiplied by the width, addressing the fields as an array.
+func parseBitfieldOffset(arg string, bits int) (int64, error) {
+	num, mul := arg, int64(1)
+	if strings.HasPrefix(arg, "#") {
+		num, mul = arg[1:], int64(bits)
+	}
+
+	offset, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || offset < 0 || offset > (storage.MaxBitOffset-int64(bits)+1)/mul {
+		return 0, errors.ErrBitOffset
+	}
+
+	return offset * mul, nil
+}
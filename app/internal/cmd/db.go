@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func handleCopy(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		replace bool
+		dstDB   = db
+	)
+	for i := 3; i < len(cmd); i++ {
+		switch strings.ToUpper(cmd[i].BulkStrs()) {
+		case "REPLACE":
+			replace = true
+
+		case "DB":
+			if i+1 >= len(cmd) {
+				return "", errors.ErrSyntax
+			}
+			i++
+
+			val, err := ParseDBIndex(cmd[i], len(dbs))
+			if err != nil {
+				return "", err
+			}
+
+			dstDB = val
+
+		default:
+			return "", errors.ErrSyntax
+		}
+	}
+
+	src, dst := cmd[1].BulkStrs(), cmd[2].BulkStrs()
+	if src == dst && db == dstDB {
+		return "", errors.ErrSameObject
+	}
+
+	copied := storage.Copy(dbs[db], dbs[dstDB], src, dst, replace)
+	return protocol.ToIntegers(boolToInt(copied)), nil
+}
+
+func handleMove(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	dstDB, err := ParseDBIndex(cmd[2], len(dbs))
+	if err != nil {
+		return "", err
+	}
+	if dstDB == db {
+		return "", errors.ErrSameObject
+	}
+
+	moved := storage.Move(dbs[db], dbs[dstDB], cmd[1].BulkStrs())
+	return protocol.ToIntegers(boolToInt(moved)), nil
+}
+
+func handleSwapdb(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	a, err := ParseDBIndex(cmd[1], len(dbs))
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ParseDBIndex(cmd[2], len(dbs))
+	if err != nil {
+		return "", err
+	}
+
+	if a != b {
+		storage.Swap(dbs[a], dbs[b])
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func handleFlushdb(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	async, err := parseFlushMode(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	store.Flush(async)
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func handleFlushall(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	async, err := parseFlushMode(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	for _, store := range dbs {
+		store.Flush(async)
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func (r *Registry) handleInfo(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	section := "default"
+	if len(cmd) > 1 {
+		section = strings.ToLower(cmd[1].BulkStrs())
+	}
+	all := section == "default" || section == "all" || section == "everything"
+
+	var sections []string
+	if all || section == "memory" {
+		used := storage.UsedMemory(dbs)
+		sections = append(sections, "# Memory\r\n"+
+			fmt.Sprintf("used_memory:%d\r\n", used)+
+			fmt.Sprintf("used_memory_human:%s\r\n", bytesToHuman(used))+
+			fmt.Sprintf("maxmemory:%d\r\n", r.evictor.MaxMemory)+
+			fmt.Sprintf("maxmemory_human:%s\r\n", bytesToHuman(r.evictor.MaxMemory))+
+			fmt.Sprintf("maxmemory_policy:%s\r\n", r.evictor.Policy))
+	}
+
+	if all || section == "stats" {
+		sections = append(sections, "# Stats\r\n"+
+			fmt.Sprintf("evicted_keys:%d\r\n", r.evictor.Evicted()))
+	}
+
+	if all || section == "keyspace" {
+		info := "# Keyspace\r\n"
+		for i, store := range dbs {
+			keys, expires, avgTTL := store.KeyspaceInfo()
+			if keys == 0 {
+				continue
+			}
+
+			info += fmt.Sprintf("db%d:keys=%d,expires=%d,avg_ttl=%d\r\n", i, keys, expires, avgTTL.Milliseconds())
+		}
+		sections = append(sections, info)
+	}
+
+	return protocol.ToBulkStr(strings.Join(sections, "\r\n")), nil
+}
+
+// bytesToHuman formats the number of bytes in the human readable units.
+func bytesToHuman(n int64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+
+	val := float64(n)
+	i := 0
+	for val >= 1024 && i < len(units)-1 {
+		val /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.2f%s", val, units[i])
+}
+
+// ParseDBIndex parses the index of the logical database out of the argument.
+func ParseDBIndex(arg *protocol.RespVal, databases int) (int, error) {
+	idx, err := strconv.Atoi(arg.BulkStrs())
+	if err != nil {
+		return 0, errors.ErrNotANumericValue
+	}
+	if idx < 0 || idx >= databases {
+		return 0, errors.ErrDBIndexOutOfRange
+	}
+
+	return idx, nil
+}
+
+// parseFlushMode parses the optional "ASYNC" or "SYNC" argument of the flush commands.
+func parseFlushMode(cmd []*protocol.RespVal) (bool, error) {
+	if len(cmd) < 2 {
+		return false, nil
+	}
+	if len(cmd) > 2 {
+		return false, errors.ErrSyntax
+	}
+
+	switch strings.ToUpper(cmd[1].BulkStrs()) {
+	case "ASYNC":
+		return true, nil
+	case "SYNC":
+		return false, nil
+	default:
+		return false, errors.ErrSyntax
+	}
+}
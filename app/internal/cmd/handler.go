@@ -8,40 +8,200 @@ import (
 // Handler represents a command handler function.
 type Handler func(cmd []*protocol.RespVal, store *storage.Mem) (string, error)
 
+// DBHandler represents a handler of the command which works across the logical databases.
+// It is given all the databases along with the index of the connection's selected database.
+type DBHandler func(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error)
+
+// Flag describes the behaviour of a command.
+type Flag int
+
+const (
+	// FlagDenyOOM marks the command which may increase the memory usage, hence the keys are evicted
+	// before executing it and it's rejected if the memory can't be freed.
+	FlagDenyOOM Flag = 1 << iota
+)
+
 // Registry holds all registered command handlers.
 type Registry struct {
-	handlers map[string]Handler
+	handlers   map[string]Handler
+	dbHandlers map[string]DBHandler
+	flags      map[string]Flag
+	evictor    *storage.Evictor
 }
 
 // NewRegistry creates a new command registry with all handlers registered.
-func NewRegistry() *Registry {
+// The evictor keeps the databases within the memory limit.
+func NewRegistry(evictor *storage.Evictor) *Registry {
 	r := &Registry{
-		handlers: make(map[string]Handler),
+		handlers:   make(map[string]Handler),
+		dbHandlers: make(map[string]DBHandler),
+		flags:      make(map[string]Flag),
+		evictor:    evictor,
 	}
 
 	// Register all commands
 	r.Register("PING", handlePing)
 	r.Register("ECHO", handleEcho)
-	r.Register("SET", handleSet)
+	r.Register("SET", handleSet, FlagDenyOOM)
 	r.Register("GET", handleGet)
-	r.Register("RPUSH", handleRpush)
+	r.Register("SETNX", handleSetnx, FlagDenyOOM)
+	r.Register("SETEX", handleSetex, FlagDenyOOM)
+	r.Register("PSETEX", handlePsetex, FlagDenyOOM)
+	r.Register("GETEX", handleGetex)
+	r.Register("GETDEL", handleGetdel)
+	r.Register("APPEND", handleAppend, FlagDenyOOM)
+	r.Register("STRLEN", handleStrlen)
+	r.Register("GETRANGE", handleGetrange)
+	r.Register("SETRANGE", handleSetrange, FlagDenyOOM)
+	r.Register("LCS", handleLcs)
+	r.Register("MGET", handleMget)
+	r.Register("MSET", handleMset, FlagDenyOOM)
+	r.Register("MSETNX", handleMsetnx, FlagDenyOOM)
+	r.Register("SETBIT", handleSetbit, FlagDenyOOM)
+	r.Register("GETBIT", handleGetbit)
+	r.Register("BITCOUNT", handleBitcount)
+	r.Register("BITPOS", handleBitpos)
+	r.Register("BITOP", handleBitop, FlagDenyOOM)
+	r.Register("BITFIELD", handleBitfield, FlagDenyOOM)
+	r.Register("BITFIELD_RO", handleBitfieldRo)
+	r.Register("PFADD", handlePfadd, FlagDenyOOM)
+	r.Register("PFCOUNT", handlePfcount)
+	r.Register("PFMERGE", handlePfmerge, FlagDenyOOM)
+	r.Register("RPUSH", handleRpush, FlagDenyOOM)
 	r.Register("LRANGE", handleLrange)
-	r.Register("LPUSH", handleLpush)
+	r.Register("LPUSH", handleLpush, FlagDenyOOM)
 	r.Register("LLEN", handleLlen)
 	r.Register("LPOP", handleLpop)
+	r.Register("RPOP", handleRpop)
+	r.Register("LINDEX", handleLindex)
+	r.Register("LSET", handleLset)
+	r.Register("LINSERT", handleLinsert, FlagDenyOOM)
+	r.Register("LREM", handleLrem)
+	r.Register("LTRIM", handleLtrim)
+	r.Register("LPOS", handleLpos)
+	r.Register("RPUSHX", handleRpushx, FlagDenyOOM)
+	r.Register("LPUSHX", handleLpushx, FlagDenyOOM)
+	r.Register("LMOVE", handleLmove, FlagDenyOOM)
+	r.Register("RPOPLPUSH", handleRpoplpush, FlagDenyOOM)
+	r.Register("BLMOVE", handleBlmove, FlagDenyOOM)
+	r.Register("BRPOPLPUSH", handleBrpoplpush, FlagDenyOOM)
 	r.Register("BLPOP", handleBlpop)
+	r.Register("BRPOP", handleBrpop)
+	r.Register("LMPOP", handleLmpop)
+	r.Register("BLMPOP", handleBlmpop)
+	r.Register("HSET", handleHset, FlagDenyOOM)
+	r.Register("HSETNX", handleHsetnx, FlagDenyOOM)
+	r.Register("HGET", handleHget)
+	r.Register("HMGET", handleHmget)
+	r.Register("HDEL", handleHdel)
+	r.Register("HGETALL", handleHgetall)
+	r.Register("HKEYS", handleHkeys)
+	r.Register("HVALS", handleHvals)
+	r.Register("HLEN", handleHlen)
+	r.Register("HEXISTS", handleHexists)
+	r.Register("HSTRLEN", handleHstrlen)
+	r.Register("HINCRBY", handleHincrby, FlagDenyOOM)
+	r.Register("HINCRBYFLOAT", handleHincrbyfloat, FlagDenyOOM)
+	r.Register("HEXPIRE", handleHexpire)
+	r.Register("HPEXPIRE", handleHpexpire)
+	r.Register("HEXPIREAT", handleHexpireat)
+	r.Register("HPEXPIREAT", handleHpexpireat)
+	r.Register("HTTL", handleHttl)
+	r.Register("HPTTL", handleHpttl)
+	r.Register("HEXPIRETIME", handleHexpiretime)
+	r.Register("HPEXPIRETIME", handleHpexpiretime)
+	r.Register("HPERSIST", handleHpersist)
+	r.Register("HGETEX", handleHgetex)
+	r.Register("HSETEX", handleHsetex, FlagDenyOOM)
+	r.Register("SADD", handleSadd, FlagDenyOOM)
+	r.Register("SREM", handleSrem)
+	r.Register("SMEMBERS", handleSmembers)
+	r.Register("SISMEMBER", handleSismember)
+	r.Register("SMISMEMBER", handleSmismember)
+	r.Register("SCARD", handleScard)
+	r.Register("SPOP", handleSpop)
+	r.Register("SRANDMEMBER", handleSrandmember)
+	r.Register("SMOVE", handleSmove)
+	r.Register("SINTER", handleSinter)
+	r.Register("SUNION", handleSunion)
+	r.Register("SDIFF", handleSdiff)
+	r.Register("SINTERSTORE", handleSinterstore, FlagDenyOOM)
+	r.Register("SUNIONSTORE", handleSunionstore, FlagDenyOOM)
+	r.Register("SDIFFSTORE", handleSdiffstore, FlagDenyOOM)
+	r.Register("SINTERCARD", handleSintercard)
+	r.Register("ZADD", handleZadd, FlagDenyOOM)
+	r.Register("ZINCRBY", handleZincrby, FlagDenyOOM)
+	r.Register("ZREM", handleZrem)
+	r.Register("ZSCORE", handleZscore)
+	r.Register("ZMSCORE", handleZmscore)
+	r.Register("ZCARD", handleZcard)
+	r.Register("ZRANK", handleZrank)
+	r.Register("ZREVRANK", handleZrevrank)
+	r.Register("ZRANGE", handleZrange)
+	r.Register("ZREVRANGE", handleZrevrange)
+	r.Register("ZRANGEBYSCORE", handleZrangebyscore)
+	r.Register("ZREVRANGEBYSCORE", handleZrevrangebyscore)
+	r.Register("ZRANGEBYLEX", handleZrangebylex)
+	r.Register("ZREVRANGEBYLEX", handleZrevrangebylex)
+	r.Register("ZRANGESTORE", handleZrangestore, FlagDenyOOM)
+	r.Register("ZCOUNT", handleZcount)
+	r.Register("ZLEXCOUNT", handleZlexcount)
+	r.Register("ZUNION", handleZunion)
+	r.Register("ZINTER", handleZinter)
+	r.Register("ZDIFF", handleZdiff)
+	r.Register("ZUNIONSTORE", handleZunionstore, FlagDenyOOM)
+	r.Register("ZINTERSTORE", handleZinterstore, FlagDenyOOM)
+	r.Register("ZDIFFSTORE", handleZdiffstore, FlagDenyOOM)
+	r.Register("ZINTERCARD", handleZintercard)
 	r.Register("TYPE", handleType)
-	r.Register("XADD", handleXadd)
+	r.Register("XADD", handleXadd, FlagDenyOOM)
 	r.Register("XRANGE", handleXrange)
 	r.Register("XREAD", handleXread)
-	r.Register("INCR", handleIncr)
+	r.Register("INCR", handleIncr, FlagDenyOOM)
+	r.Register("INCRBY", handleIncrby, FlagDenyOOM)
+	r.Register("DECR", handleDecr, FlagDenyOOM)
+	r.Register("DECRBY", handleDecrby, FlagDenyOOM)
+	r.Register("INCRBYFLOAT", handleIncrbyfloat, FlagDenyOOM)
+	r.Register("DEL", handleDel)
+	r.Register("UNLINK", handleUnlink)
+	r.Register("EXISTS", handleExists)
+	r.Register("RENAME", handleRename)
+	r.Register("RENAMENX", handleRenamenx)
+	r.Register("TOUCH", handleTouch)
+	r.Register("DBSIZE", handleDbsize)
+	r.Register("RANDOMKEY", handleRandomkey)
+	r.Register("KEYS", handleKeys)
+	r.Register("SCAN", handleScan)
+	r.Register("FLUSHDB", handleFlushdb)
+	r.Register("OBJECT", handleObject)
+
+	// Register all the commands working across the databases
+	r.RegisterDB("COPY", handleCopy, FlagDenyOOM)
+	r.RegisterDB("MOVE", handleMove)
+	r.RegisterDB("SWAPDB", handleSwapdb)
+	r.RegisterDB("FLUSHALL", handleFlushall)
+	r.RegisterDB("INFO", r.handleInfo)
+	r.RegisterDB("MEMORY", r.handleMemory)
 
 	return r
 }
 
 // Register registers a command handler.
-func (r *Registry) Register(name string, handler Handler) {
+func (r *Registry) Register(name string, handler Handler, flags ...Flag) {
 	r.handlers[name] = handler
+	r.setFlags(name, flags)
+}
+
+// RegisterDB registers a handler of the command which works across the databases.
+func (r *Registry) RegisterDB(name string, handler DBHandler, flags ...Flag) {
+	r.dbHandlers[name] = handler
+	r.setFlags(name, flags)
+}
+
+func (r *Registry) setFlags(name string, flags []Flag) {
+	for _, flag := range flags {
+		r.flags[name] |= flag
+	}
 }
 
 // Get retrieves a command handler by name.
@@ -50,14 +210,26 @@ func (r *Registry) Get(name string) (Handler, bool) {
 	return handler, ok
 }
 
-// Execute executes a command using the registry.
-func (r *Registry) Execute(cmdName string, cmd []*protocol.RespVal, store *storage.Mem) string {
-	handler, ok := r.Get(cmdName)
-	if !ok {
+// Execute executes a command using the registry against the selected database out of dbs.
+func (r *Registry) Execute(cmdName string, cmd []*protocol.RespVal, dbs []*storage.Mem, db int) string {
+	var (
+		respStr string
+		err     error
+	)
+	if r.flags[cmdName]&FlagDenyOOM != 0 {
+		if err := r.evictor.Evict(dbs); err != nil {
+			return protocol.ToSimpErr(err.Error())
+		}
+	}
+
+	if handler, ok := r.Get(cmdName); ok {
+		respStr, err = handler(cmd, dbs[db])
+	} else if dbHandler, ok := r.dbHandlers[cmdName]; ok {
+		respStr, err = dbHandler(cmd, dbs, db)
+	} else {
 		return protocol.ToSimpErr("ERR unknown command")
 	}
 
-	respStr, err := handler(cmd, store)
 	if err != nil {
 		return protocol.ToSimpErr(err.Error())
 	}
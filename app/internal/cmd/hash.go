@@ -0,0 +1,493 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+// HSET key field value [field value ...]
+func handleHset(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 4 || len(cmd)%2 != 0 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	created, err := store.HSet(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(created)), nil
+}
+
+func handleHsetnx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	set, err := store.HSetNX(cmd[1].BulkStrs(), cmd[2].BulkStrs(), cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(set)), nil
+}
+
+func handleHget(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	val, ok, err := store.HGet(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleHmget(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	vals, err := store.HMGet(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return fieldValsToArray(vals), nil
+}
+
+// fieldValsToArray returns the array reply of the values of hash fields, nil for the missing fields.
+func fieldValsToArray(vals []any) string {
+	resps := make([]string, 0, len(vals))
+	for _, val := range vals {
+		if val == nil {
+			resps = append(resps, protocol.ToNulls())
+		} else {
+			resps = append(resps, protocol.ToBulkStr(val))
+		}
+	}
+
+	return protocol.ToArray(resps)
+}
+
+func handleHdel(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	removed, err := store.HDel(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleHgetall(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hashElems(cmd, store.HGetAll)
+}
+
+func handleHkeys(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hashElems(cmd, store.HKeys)
+}
+
+func handleHvals(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hashElems(cmd, store.HVals)
+}
+
+// hashElems handles the commands listing the fields or values of a hash.
+func hashElems(cmd []*protocol.RespVal, elemsFn func(key string) ([]any, error)) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	elems, err := elemsFn(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToArray(protocol.ToBulkStrArr(elems)), nil
+}
+
+func handleHlen(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	n, err := store.HLen(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+func handleHexists(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	ok, err := store.HExists(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(ok)), nil
+}
+
+func handleHstrlen(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	n, err := store.HStrlen(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+func handleHincrby(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	delta, err := strconv.ParseInt(cmd[3].BulkStrs(), 10, 64)
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	val, err := store.HIncrBy(cmd[1].BulkStrs(), cmd[2].BulkStrs(), delta)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(val), nil
+}
+
+func handleHincrbyfloat(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	val, err := store.HIncrByFloat(cmd[1].BulkStrs(), cmd[2].BulkStrs(), cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+// hashFieldMaxExpire is the latest unix time in milliseconds at which a hash field may expire, as in Redis
+const hashFieldMaxExpire = (1<<48 - 1) >> 2
+
+// HEXPIRE key seconds [NX|XX|GT|LT] FIELDS numfields field [field ...]
+func handleHexpire(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpire(cmd, store, "hexpire", false, false)
+}
+
+func handleHpexpire(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpire(cmd, store, "hpexpire", true, false)
+}
+
+func handleHexpireat(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpire(cmd, store, "hexpireat", false, true)
+}
+
+func handleHpexpireat(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpire(cmd, store, "hpexpireat", true, true)
+}
+
+// hexpire handles the commands setting the time-to-live of hash fields, given in seconds or in
+// milliseconds if ms is true, relative to now or as a unix time if abs is true.
+func hexpire(cmd []*protocol.RespVal, store *storage.Mem, cmdName string, ms, abs bool) (string, error) {
+	if len(cmd) < 6 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	at, err := strconv.ParseInt(cmd[2].BulkStrs(), 10, 64)
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+	if at < 0 {
+		return "", errors.ErrExpireNegative
+	}
+
+	// Convert the expiry to the unix milliseconds, making sure it doesn't overflow
+	if !ms {
+		if at > hashFieldMaxExpire/1000 {
+			return "", errors.ErrInvalidExpireTime(cmdName)
+		}
+		at *= 1000
+	}
+	if at > hashFieldMaxExpire {
+		return "", errors.ErrInvalidExpireTime(cmdName)
+	}
+	if !abs {
+		at += time.Now().UnixMilli()
+		if at > hashFieldMaxExpire {
+			return "", errors.ErrInvalidExpireTime(cmdName)
+		}
+	}
+
+	cond, fieldsIdx := storage.FieldExpireAlways, 4
+	switch strings.ToUpper(cmd[3].BulkStrs()) {
+	case "NX":
+		cond = storage.FieldExpireNX
+	case "XX":
+		cond = storage.FieldExpireXX
+	case "GT":
+		cond = storage.FieldExpireGT
+	case "LT":
+		cond = storage.FieldExpireLT
+	default:
+		fieldsIdx = 3
+	}
+
+	fields, err := parseFields(cmd, fieldsIdx, 1)
+	if err != nil {
+		return "", err
+	}
+
+	results, err := store.HExpire(cmd[1].BulkStrs(), time.UnixMilli(at), cond, fields...)
+	if err != nil {
+		return "", err
+	}
+
+	resps := make([]string, len(results))
+	for i, res := range results {
+		resps[i] = protocol.ToIntegers(int64(res))
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+// parseFields parses the "FIELDS numfields field ..." arguments starting at the index, which must
+// take up the rest of the command. Each field is followed by perField-1 more arguments, such as its
+// value.
+func parseFields(cmd []*protocol.RespVal, idx, perField int) ([]string, error) {
+	if idx+1 >= len(cmd) || strings.ToUpper(cmd[idx].BulkStrs()) != "FIELDS" {
+		return nil, errors.ErrFieldsMissing
+	}
+
+	n, err := strconv.Atoi(cmd[idx+1].BulkStrs())
+	if err != nil || n <= 0 {
+		return nil, errors.ErrNumFields
+	}
+
+	args := cmd[idx+2:]
+	if n > len(args)/perField || len(args) != n*perField {
+		return nil, errors.ErrNumFieldsMismatch
+	}
+
+	return toStrs(args), nil
+}
+
+// HTTL key FIELDS numfields field [field ...]
+func handleHttl(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpireTime(cmd, store, func(at int64) int64 {
+		// Round up, so that a field which hasn't expired yet doesn't report 0
+		return (at - time.Now().UnixMilli() + 999) / 1000
+	})
+}
+
+func handleHpttl(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpireTime(cmd, store, func(at int64) int64 {
+		return max(at-time.Now().UnixMilli(), 0)
+	})
+}
+
+func handleHexpiretime(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpireTime(cmd, store, func(at int64) int64 {
+		return at / 1000
+	})
+}
+
+func handleHpexpiretime(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return hexpireTime(cmd, store, func(at int64) int64 {
+		return at
+	})
+}
+
+// hexpireTime handles the commands getting the time-to-live of hash fields, converting the unix
+// milliseconds at which each field expires with the given function.
+func hexpireTime(cmd []*protocol.RespVal, store *storage.Mem, convert func(at int64) int64) (string, error) {
+	if len(cmd) < 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	fields, err := parseFields(cmd, 2, 1)
+	if err != nil {
+		return "", err
+	}
+
+	times, err := store.HExpireTime(cmd[1].BulkStrs(), fields...)
+	if err != nil {
+		return "", err
+	}
+
+	resps := make([]string, len(times))
+	for i, at := range times {
+		if at >= 0 {
+			at = convert(at)
+		}
+		resps[i] = protocol.ToIntegers(at)
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+// HPERSIST key FIELDS numfields field [field ...]
+func handleHpersist(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	fields, err := parseFields(cmd, 2, 1)
+	if err != nil {
+		return "", err
+	}
+
+	results, err := store.HPersist(cmd[1].BulkStrs(), fields...)
+	if err != nil {
+		return "", err
+	}
+
+	resps := make([]string, len(results))
+	for i, res := range results {
+		resps[i] = protocol.ToIntegers(int64(res))
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+// HGETEX key [EX seconds|PX milliseconds|EXAT unix-seconds|PXAT unix-milliseconds|PERSIST] FIELDS numfields field [field ...]
+func handleHgetex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		expireAt time.Time
+		persist  bool
+		idx      = 2
+	)
+	switch opt := strings.ToUpper(cmd[idx].BulkStrs()); opt {
+	case "PERSIST":
+		persist = true
+		idx++
+
+	case "EX", "PX", "EXAT", "PXAT":
+		if idx+1 >= len(cmd) {
+			return "", errors.ErrSyntax
+		}
+
+		at, err := parseFieldExpireAt(opt, cmd[idx+1], "hgetex")
+		if err != nil {
+			return "", err
+		}
+		expireAt = at
+		idx += 2
+	}
+
+	fields, err := parseFields(cmd, idx, 1)
+	if err != nil {
+		return "", err
+	}
+
+	vals, err := store.HGetEx(cmd[1].BulkStrs(), fields, expireAt, persist)
+	if err != nil {
+		return "", err
+	}
+
+	return fieldValsToArray(vals), nil
+}
+
+// HSETEX key [FNX|FXX] [EX seconds|PX milliseconds|EXAT unix-seconds|PXAT unix-milliseconds|KEEPTTL] FIELDS numfields field value [field value ...]
+func handleHsetex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 6 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		opts      storage.HSetExOpts
+		hasExpire bool
+		idx       = 2
+	)
+	for idx < len(cmd) {
+		opt := strings.ToUpper(cmd[idx].BulkStrs())
+		if opt == "FIELDS" {
+			break
+		}
+
+		switch opt {
+		case "FNX", "FXX":
+			if opts.FNX || opts.FXX {
+				return "", errors.ErrSyntax
+			}
+			opts.FNX = opt == "FNX"
+			opts.FXX = opt == "FXX"
+			idx++
+
+		case "KEEPTTL":
+			if hasExpire {
+				return "", errors.ErrSyntax
+			}
+			hasExpire = true
+			opts.KeepTTL = true
+			idx++
+
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpire || idx+1 >= len(cmd) {
+				return "", errors.ErrSyntax
+			}
+			hasExpire = true
+
+			at, err := parseFieldExpireAt(opt, cmd[idx+1], "hsetex")
+			if err != nil {
+				return "", err
+			}
+			opts.ExpireAt = at
+			idx += 2
+
+		default:
+			return "", errors.ErrFieldsMissing
+		}
+	}
+
+	pairs, err := parseFields(cmd, idx, 2)
+	if err != nil {
+		return "", err
+	}
+
+	set, err := store.HSetEx(cmd[1].BulkStrs(), pairs, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(set)), nil
+}
+
+// parseFieldExpireAt is like parseExpireAt, also making sure the hash field may expire at that time.
+func parseFieldExpireAt(opt string, arg *protocol.RespVal, cmdName string) (time.Time, error) {
+	at, err := parseExpireAt(opt, arg, cmdName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if at.UnixMilli() > hashFieldMaxExpire {
+		return time.Time{}, errors.ErrInvalidExpireTime(cmdName)
+	}
+
+	return at, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func handlePfadd(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	updated, err := store.PFAdd(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(updated)), nil
+}
+
+func handlePfcount(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	card, err := store.PFCount(toStrs(cmd[1:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(card), nil
+}
+
+func handlePfmerge(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	if err := store.PFMerge(cmd[1].BulkStrs(), toStrs(cmd[2:])...); err != nil {
+		return "", err
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+const (
+	// memoryUsageSamples is the default number of elements sampled by "MEMORY USAGE"
+	memoryUsageSamples = 5
+	// doctorMinDataset is the dataset size below which "MEMORY DOCTOR" doesn't look for issues
+	doctorMinDataset = 5 * 1024 * 1024
+)
+
+func handleObject(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	subCmd := strings.ToUpper(cmd[1].BulkStrs())
+	switch subCmd {
+	case "HELP":
+		return protocol.ToArray(protocol.ToBulkStrArr([]any{
+			"OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+			"ENCODING <key>",
+			"    Return the kind of internal representation used in order to store the value associated with a <key>.",
+			"FREQ <key>",
+			"    Return the access frequency index of the <key>. The returned integer is proportional to the logarithm of the recent access frequency of the key.",
+			"IDLETIME <key>",
+			"    Return the idle time of the <key>, that is the approximated number of seconds elapsed since the last access to the key.",
+			"REFCOUNT <key>",
+			"    Return the number of references of the value associated with the specified <key>.",
+		})), nil
+	case "ENCODING", "IDLETIME", "FREQ", "REFCOUNT":
+	default:
+		// The subcommand is checked first, so that it's reported even if the key is missing
+		return "", fmt.Errorf("ERR unknown subcommand '%s'. Try OBJECT HELP.", cmd[1].BulkStrs())
+	}
+
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	info, ok := store.Object(cmd[2].BulkStrs())
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	switch subCmd {
+	case "ENCODING":
+		return protocol.ToBulkStr(info.Encoding), nil
+	case "IDLETIME":
+		return protocol.ToIntegers(int64(info.IdleTime.Seconds())), nil
+	case "FREQ":
+		return protocol.ToIntegers(int64(info.Freq)), nil
+	default:
+		return protocol.ToIntegers(int64(info.RefCount)), nil
+	}
+}
+
+func (r *Registry) handleMemory(cmd []*protocol.RespVal, dbs []*storage.Mem, db int) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	switch strings.ToUpper(cmd[1].BulkStrs()) {
+	case "USAGE":
+		return handleMemoryUsage(cmd, dbs[db])
+	case "STATS":
+		return handleMemoryStats(dbs), nil
+	case "DOCTOR":
+		return protocol.ToBulkStr(r.memoryDoctor(dbs)), nil
+	default:
+		return "", fmt.Errorf("ERR unknown subcommand '%s'. Try MEMORY HELP.", cmd[1].BulkStrs())
+	}
+}
+
+func handleMemoryUsage(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 && len(cmd) != 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	samples := memoryUsageSamples
+	if len(cmd) == 5 {
+		if strings.ToUpper(cmd[3].BulkStrs()) != "SAMPLES" {
+			return "", errors.ErrSyntax
+		}
+
+		val, err := strconv.Atoi(cmd[4].BulkStrs())
+		if err != nil || val < 0 {
+			return "", errors.ErrNotANumericValue
+		}
+
+		samples = val
+	}
+
+	usage, ok := store.MemoryUsage(cmd[2].BulkStrs(), samples)
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToIntegers(usage), nil
+}
+
+func handleMemoryStats(dbs []*storage.Mem) string {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var (
+		keys, overhead int64
+		dbStats        []string
+	)
+	for i, store := range dbs {
+		dbKeys, _, _ := store.KeyspaceInfo()
+		if dbKeys == 0 {
+			continue
+		}
+
+		main, expires := store.Overhead()
+		keys += int64(dbKeys)
+		overhead += main + expires
+
+		dbStats = append(dbStats,
+			protocol.ToBulkStr(fmt.Sprintf("db.%d", i)),
+			protocol.ToArray([]string{
+				protocol.ToBulkStr("overhead.hashtable.main"), protocol.ToIntegers(main),
+				protocol.ToBulkStr("overhead.hashtable.expires"), protocol.ToIntegers(expires),
+			}),
+		)
+	}
+
+	used := storage.UsedMemory(dbs)
+	dataset := max(used-overhead, 0)
+
+	var bytesPerKey, datasetPercentage int64
+	if keys > 0 {
+		bytesPerKey = used / keys
+	}
+	if ms.HeapAlloc > 0 {
+		datasetPercentage = dataset * 100 / int64(ms.HeapAlloc)
+	}
+
+	stats := []string{
+		protocol.ToBulkStr("total.allocated"), protocol.ToIntegers(int64(ms.HeapAlloc)),
+		protocol.ToBulkStr("overhead.total"), protocol.ToIntegers(overhead),
+	}
+	stats = append(stats, dbStats...)
+	stats = append(stats,
+		protocol.ToBulkStr("keys.count"), protocol.ToIntegers(keys),
+		protocol.ToBulkStr("keys.bytes-per-key"), protocol.ToIntegers(bytesPerKey),
+		protocol.ToBulkStr("dataset.bytes"), protocol.ToIntegers(dataset),
+		protocol.ToBulkStr("dataset.percentage"), protocol.ToBulkStr(strconv.FormatInt(datasetPercentage, 10)),
+	)
+
+	return protocol.ToArray(stats)
+}
+
+// memoryDoctor reports the memory issues detected out of the current usage.
+func (r *Registry) memoryDoctor(dbs []*storage.Mem) string {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	used := storage.UsedMemory(dbs)
+	if used < doctorMinDataset {
+		return "Hi Sam, this instance is empty or is using very little memory, my issues detector can't be used in these conditions. Please, fill it with some data and come back."
+	}
+
+	var issues []string
+	if heap := int64(ms.HeapAlloc); heap > used*2 {
+		issues = append(issues, fmt.Sprintf(" * High heap overhead: the heap holds %s while the keys account for %s. "+
+			"It's either garbage waiting to be collected, e.g. after flushing or unlinking large keys, or the per-key estimate falls short for this dataset.",
+			bytesToHuman(heap), bytesToHuman(used)))
+	}
+
+	if maxMemory := r.evictor.MaxMemory; maxMemory > 0 && used*10 >= maxMemory*9 {
+		outcome := "the keys are evicted once the limit is reached."
+		if r.evictor.Policy == storage.NoEviction {
+			outcome = "the write commands are rejected once the limit is reached."
+		}
+
+		issues = append(issues, fmt.Sprintf(" * Close to the memory limit: %s out of %s is used. With the '%s' policy, %s",
+			bytesToHuman(used), bytesToHuman(maxMemory), r.evictor.Policy, outcome))
+	}
+
+	if evicted := r.evictor.Evicted(); evicted > 0 {
+		issues = append(issues, fmt.Sprintf(" * Evicted keys: %d keys were evicted to stay within the memory limit. "+
+			"Consider raising 'maxmemory' if those keys are still needed.", evicted))
+	}
+
+	if len(issues) == 0 {
+		return "Hi Sam, I can't find any memory issue in your instance. I can only account for what occurs on this base."
+	}
+
+	return "Sam, I detected a few issues in this instance memory implementation:\n\n" + strings.Join(issues, "\n\n") + "\n"
+}
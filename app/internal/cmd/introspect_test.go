@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func TestObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "encoding", args: []string{"ENCODING", "k"}, want: protocol.ToBulkStr("int")},
+		{name: "refcount", args: []string{"refcount", "k"}, want: protocol.ToIntegers(1)},
+		{name: "missing key", args: []string{"ENCODING", "missing"}, want: protocol.ToNulls()},
+		{name: "unknown subcommand", args: []string{"BOGUS", "k"}, wantErr: "ERR unknown subcommand 'BOGUS'. Try OBJECT HELP."},
+		// The subcommand is checked before the key is looked up
+		{name: "unknown subcommand of a missing key", args: []string{"bogus", "missing"}, wantErr: "ERR unknown subcommand 'bogus'. Try OBJECT HELP."},
+		{name: "unknown subcommand without a key", args: []string{"BOGUS"}, wantErr: "ERR unknown subcommand 'BOGUS'. Try OBJECT HELP."},
+		{name: "missing key argument", args: []string{"ENCODING"}, wantErr: errors.ErrInvalidCmd.Error()},
+		{name: "extra argument", args: []string{"ENCODING", "k", "x"}, wantErr: errors.ErrInvalidCmd.Error()},
+	}
+
+	store := storage.NewMem()
+	store.SetWithOpts("k", "12", storage.SetOpts{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handleObject(bulkStrs(append([]string{"OBJECT"}, tt.args...)...), store)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("OBJECT error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OBJECT error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("OBJECT = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func handleDel(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	removed := store.Del(toStrs(cmd[1:])...)
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleUnlink(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	removed := store.Unlink(toStrs(cmd[1:])...)
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleExists(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	cnt := store.Exists(toStrs(cmd[1:])...)
+	return protocol.ToIntegers(int64(cnt)), nil
+}
+
+func handleRename(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	if _, err := store.Rename(cmd[1].BulkStrs(), cmd[2].BulkStrs(), false); err != nil {
+		return "", err
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func handleRenamenx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	renamed, err := store.Rename(cmd[1].BulkStrs(), cmd[2].BulkStrs(), true)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(renamed)), nil
+}
+
+func handleTouch(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	cnt := store.Touch(toStrs(cmd[1:])...)
+	return protocol.ToIntegers(int64(cnt)), nil
+}
+
+func handleDbsize(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return protocol.ToIntegers(int64(store.DBSize())), nil
+}
+
+func handleRandomkey(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	key, ok := store.RandomKey()
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(key), nil
+}
+
+func handleKeys(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys := store.Keys(cmd[1].BulkStrs())
+	return protocol.ToArray(protocol.ToBulkStrArr(toAnys(keys))), nil
+}
+
+func handleScan(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	cursor, err := strconv.ParseUint(cmd[1].BulkStrs(), 10, 64)
+	if err != nil {
+		return "", errors.ErrInvalidCursor
+	}
+
+	var (
+		count        = 10
+		pattern, typ string
+	)
+	for i := 2; i < len(cmd); i += 2 {
+		if i+1 >= len(cmd) {
+			return "", errors.ErrSyntax
+		}
+
+		val := cmd[i+1].BulkStrs()
+		switch strings.ToUpper(cmd[i].BulkStrs()) {
+		case "MATCH":
+			pattern = val
+
+		case "COUNT":
+			count, err = strconv.Atoi(val)
+			if err != nil {
+				return "", errors.ErrNotANumericValue
+			}
+			if count < 1 {
+				return "", errors.ErrSyntax
+			}
+
+		case "TYPE":
+			typ = strings.ToLower(val)
+
+		default:
+			return "", errors.ErrSyntax
+		}
+	}
+
+	keys, cursor := store.Scan(cursor, count, pattern, typ)
+	return protocol.ToArray([]string{
+		protocol.ToBulkStr(strconv.FormatUint(cursor, 10)),
+		protocol.ToArray(protocol.ToBulkStrArr(toAnys(keys))),
+	}), nil
+}
+
+// toStrs converts the bulk string arguments to the list of strings
+func toStrs(args []*protocol.RespVal) []string {
+	strs := make([]string, 0, len(args))
+	for _, arg := range args {
+		strs = append(strs, arg.BulkStrs())
+	}
+
+	return strs
+}
+
+// parseNumKeys parses the numkeys key [key ...] arguments of the commands taking several keys. It
+// returns the keys and the arguments following them, errNotPositive if numkeys isn't positive, and
+// errTooMany if there are fewer arguments than numkeys.
+func parseNumKeys(args []*protocol.RespVal, errNotPositive, errTooMany error) ([]string, []*protocol.RespVal, error) {
+	numKeys, err := strconv.Atoi(args[0].BulkStrs())
+	if err != nil {
+		return nil, nil, errors.ErrNotANumericValue
+	}
+	if numKeys <= 0 {
+		return nil, nil, errNotPositive
+	}
+	// numKeys may be large enough to overflow if added to
+	if numKeys > len(args)-1 {
+		return nil, nil, errTooMany
+	}
+
+	return toStrs(args[1 : 1+numKeys]), args[1+numKeys:], nil
+}
+
+// toAnys converts the list of strings to the list of values to be encoded
+func toAnys(strs []string) []any {
+	vals := make([]any, 0, len(strs))
+	for _, str := range strs {
+		vals = append(vals, str)
+	}
+
+	return vals
+}
+
+// boolToInt converts the boolean to the integer reply value
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+)
+
+// bulkStrs returns the arguments as bulk strings, as they're read from the client.
+func bulkStrs(args ...string) []*protocol.RespVal {
+	vals := make([]*protocol.RespVal, len(args))
+	for i, arg := range args {
+		vals[i] = &protocol.RespVal{Typ: protocol.BulkStrs, Val: arg}
+	}
+	return vals
+}
+
+func TestParseNumKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantKeys []string
+		wantRest []string
+		wantErr  error
+	}{
+		{name: "single key", args: []string{"1", "a"}, wantKeys: []string{"a"}},
+		{name: "keys and options", args: []string{"2", "a", "b", "LIMIT", "1"}, wantKeys: []string{"a", "b"}, wantRest: []string{"LIMIT", "1"}},
+		{name: "numkeys covering the options", args: []string{"3", "a", "LIMIT", "1"}, wantKeys: []string{"a", "LIMIT", "1"}},
+		{name: "zero numkeys", args: []string{"0", "a"}, wantErr: errors.ErrNumkeys},
+		{name: "negative numkeys", args: []string{"-1", "a"}, wantErr: errors.ErrNumkeys},
+		{name: "min numkeys", args: []string{"-9223372036854775808", "a"}, wantErr: errors.ErrNumkeys},
+		{name: "numkeys past the arguments", args: []string{"2", "a"}, wantErr: errors.ErrNumkeysTooMany},
+		{name: "max numkeys", args: []string{"9223372036854775807", "a"}, wantErr: errors.ErrNumkeysTooMany},
+		{name: "numkeys past max", args: []string{"9223372036854775808", "a"}, wantErr: errors.ErrNotANumericValue},
+		{name: "numkeys not a number", args: []string{"a", "b"}, wantErr: errors.ErrNotANumericValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, rest, err := parseNumKeys(bulkStrs(tt.args...), errors.ErrNumkeys, errors.ErrNumkeysTooMany)
+			if err != tt.wantErr {
+				t.Fatalf("parseNumKeys() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(keys, tt.wantKeys) || !slices.Equal(toStrs(rest), tt.wantRest) {
+				t.Errorf("parseNumKeys() = %v, %v, want %v, %v", keys, toStrs(rest), tt.wantKeys, tt.wantRest)
+			}
+		})
+	}
+}
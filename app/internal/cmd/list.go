@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"gokv/app/internal/errors"
@@ -15,12 +17,11 @@ func handleRpush(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", errors.ErrInvalidCmd
 	}
 
-	vals := make([]any, len(cmd)-2)
-	for i := 2; i < len(cmd); i++ {
-		vals[i-2] = cmd[i].BulkStrs()
+	listLen, err := store.Rpush(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
 	}
 
-	listLen := store.Rpush(cmd[1].BulkStrs(), vals...)
 	return protocol.ToIntegers(int64(listLen)), nil
 }
 
@@ -39,7 +40,11 @@ func handleLrange(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", fmt.Errorf("invalid 'stop' index")
 	}
 
-	vals := store.Lrange(cmd[1].BulkStrs(), start, stop)
+	vals, err := store.Lrange(cmd[1].BulkStrs(), start, stop)
+	if err != nil {
+		return "", err
+	}
+
 	return protocol.ToArray(protocol.ToBulkStrArr(vals)), nil
 }
 
@@ -48,12 +53,11 @@ func handleLpush(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", errors.ErrInvalidCmd
 	}
 
-	vals := make([]any, len(cmd)-2)
-	for i := 2; i < len(cmd); i++ {
-		vals[i-2] = cmd[i].BulkStrs()
+	listLen, err := store.Lpush(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
 	}
 
-	listLen := store.Lpush(cmd[1].BulkStrs(), vals...)
 	return protocol.ToIntegers(int64(listLen)), nil
 }
 
@@ -62,51 +66,478 @@ func handleLlen(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", errors.ErrInvalidCmd
 	}
 
-	len := store.Llen(cmd[1].BulkStrs())
+	len, err := store.Llen(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
 	return protocol.ToIntegers(int64(len)), nil
 }
 
 func handleLpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
-	if len(cmd) < 2 {
+	return pop(cmd, store.Lpop)
+}
+
+func handleRpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return pop(cmd, store.Rpop)
+}
+
+// pop handles "LPOP" and "RPOP", replying with a single element, or an array of them if the count
+// is given.
+func pop(cmd []*protocol.RespVal, popFn func(key string, remCnt int) ([]any, error)) (string, error) {
+	if len(cmd) < 2 || len(cmd) > 3 {
 		return "", errors.ErrInvalidCmd
 	}
 
 	remCnt := 1
 	if len(cmd) == 3 {
 		val, err := strconv.Atoi(cmd[2].BulkStrs())
-		if err != nil {
-			return "", fmt.Errorf("invalid 'count' value")
+		if err != nil || val < 0 {
+			return "", errors.ErrNotPositive
 		}
 
 		remCnt = val
 	}
 
-	removed := store.Lpop(cmd[1].BulkStrs(), remCnt)
-	if removed == nil {
-		return protocol.ToNulls(), nil
+	removed, err := popFn(cmd[1].BulkStrs(), remCnt)
+	if err != nil {
+		return "", err
 	}
-	if len(removed) == 1 {
-		return protocol.ToBulkStr(removed[0]), nil
+
+	if len(cmd) == 3 {
+		if removed == nil {
+			return protocol.ToArray(nil), nil
+		}
+		return protocol.ToArray(protocol.ToBulkStrArr(removed)), nil
 	}
 
-	return protocol.ToArray(protocol.ToBulkStrArr(removed)), nil
+	if removed == nil {
+		return protocol.ToNulls(), nil
+	}
+	return protocol.ToBulkStr(removed[0]), nil
 }
 
+// BLPOP key [key ...] timeout
 func handleBlpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return bpop(cmd, store, storage.ListLeft)
+}
+
+// BRPOP key [key ...] timeout
+func handleBrpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return bpop(cmd, store, storage.ListRight)
+}
+
+func bpop(cmd []*protocol.RespVal, store *storage.Mem, side storage.ListSide) (string, error) {
 	if len(cmd) < 3 {
 		return "", errors.ErrInvalidCmd
 	}
 
-	dur, err := strconv.ParseFloat(cmd[2].BulkStrs(), 64)
+	timeout, err := parseTimeout(cmd[len(cmd)-1].BulkStrs())
 	if err != nil {
-		return "", fmt.Errorf("invalid expiry value")
+		return "", err
 	}
 
-	key := cmd[1].BulkStrs()
-	removed := store.Blpop(key, time.Duration(dur*float64(time.Second)))
-	if removed == nil {
+	popped, err := store.Bpop(toStrs(cmd[1:len(cmd)-1]), side, 1, timeout)
+	if err != nil {
+		return "", err
+	}
+	if popped == nil {
 		return protocol.ToArray(nil), nil
 	}
 
-	return protocol.ToArray(protocol.ToBulkStrArr([]any{key, removed})), nil
+	return protocol.ToArray(protocol.ToBulkStrArr([]any{popped.Key, popped.Vals[0]})), nil
+}
+
+func handleRpushx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	listLen, err := store.Rpushx(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(listLen)), nil
+}
+
+func handleLpushx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	listLen, err := store.Lpushx(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(listLen)), nil
+}
+
+func handleLindex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	idx, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	val, ok, err := store.Lindex(cmd[1].BulkStrs(), idx)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleLset(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	idx, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	if err := store.Lset(cmd[1].BulkStrs(), idx, cmd[3].BulkStrs()); err != nil {
+		return "", err
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+// LINSERT key <BEFORE | AFTER> pivot element
+func handleLinsert(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var before bool
+	switch strings.ToUpper(cmd[2].BulkStrs()) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return "", errors.ErrSyntax
+	}
+
+	listLen, err := store.Linsert(cmd[1].BulkStrs(), before, cmd[3].BulkStrs(), cmd[4].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(listLen)), nil
+}
+
+func handleLrem(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	count, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	removed, err := store.Lrem(cmd[1].BulkStrs(), count, cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleLtrim(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	start, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	stop, err := strconv.Atoi(cmd[3].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	if err := store.Ltrim(cmd[1].BulkStrs(), start, stop); err != nil {
+		return "", err
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+// LPOS key element [RANK rank] [COUNT num-matches] [MAXLEN len]
+func handleLpos(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	opts := storage.LposOpts{Rank: 1}
+	withCount := false
+	for i := 3; i < len(cmd); i += 2 {
+		if i+1 == len(cmd) {
+			return "", errors.ErrSyntax
+		}
+
+		val, err := strconv.Atoi(cmd[i+1].BulkStrs())
+		if err != nil {
+			return "", errors.ErrNotANumericValue
+		}
+
+		switch strings.ToUpper(cmd[i].BulkStrs()) {
+		case "RANK":
+			if val == 0 || val == math.MinInt {
+				return "", errors.ErrLposRank
+			}
+			opts.Rank = val
+		case "COUNT":
+			if val < 0 {
+				return "", errors.ErrLposCount
+			}
+			opts.Count = val
+			withCount = true
+		case "MAXLEN":
+			if val < 0 {
+				return "", errors.ErrLposMaxlen
+			}
+			opts.MaxLen = val
+		default:
+			return "", errors.ErrSyntax
+		}
+	}
+
+	matches, err := store.Lpos(cmd[1].BulkStrs(), cmd[2].BulkStrs(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	if !withCount {
+		if len(matches) == 0 {
+			return protocol.ToNulls(), nil
+		}
+		return protocol.ToIntegers(int64(matches[0])), nil
+	}
+
+	elems := make([]string, len(matches))
+	for i, idx := range matches {
+		elems[i] = protocol.ToIntegers(int64(idx))
+	}
+
+	return protocol.ToArray(elems), nil
+}
+
+// LMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT>
+func handleLmove(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	from, to, err := parseListSides(cmd[3], cmd[4])
+	if err != nil {
+		return "", err
+	}
+
+	return lmove(store, cmd[1].BulkStrs(), cmd[2].BulkStrs(), from, to, -1)
+}
+
+func handleRpoplpush(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	return lmove(store, cmd[1].BulkStrs(), cmd[2].BulkStrs(), storage.ListRight, storage.ListLeft, -1)
+}
+
+// BLMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT> timeout
+func handleBlmove(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 6 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	from, to, err := parseListSides(cmd[3], cmd[4])
+	if err != nil {
+		return "", err
+	}
+
+	timeout, err := parseTimeout(cmd[5].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return lmove(store, cmd[1].BulkStrs(), cmd[2].BulkStrs(), from, to, timeout)
+}
+
+func handleBrpoplpush(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	timeout, err := parseTimeout(cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return lmove(store, cmd[1].BulkStrs(), cmd[2].BulkStrs(), storage.ListRight, storage.ListLeft, timeout)
+}
+
+// lmove moves an element between the lists, blocking up to the timeout unless it's negative.
+func lmove(store *storage.Mem, src, dst string, from, to storage.ListSide, timeout time.Duration) (string, error) {
+	var (
+		val any
+		err error
+	)
+	if timeout < 0 {
+		val, err = store.Lmove(src, dst, from, to)
+	} else {
+		val, err = store.Blmove(src, dst, from, to, timeout)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	if val == nil {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+// parseListSides parses the <LEFT | RIGHT> arguments of the side to pop from and the side to push to.
+func parseListSides(fromArg, toArg *protocol.RespVal) (storage.ListSide, storage.ListSide, error) {
+	from, err := parseListSide(fromArg.BulkStrs())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	to, err := parseListSide(toArg.BulkStrs())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return from, to, nil
+}
+
+func parseListSide(arg string) (storage.ListSide, error) {
+	switch strings.ToUpper(arg) {
+	case "LEFT":
+		return storage.ListLeft, nil
+	case "RIGHT":
+		return storage.ListRight, nil
+	default:
+		return 0, errors.ErrSyntax
+	}
+}
+
+// parseTimeout parses the timeout of the blocking commands, given in seconds with a fractional part.
+func parseTimeout(arg string) (time.Duration, error) {
+	secs, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(secs) || math.IsInf(secs, 0) {
+		return 0, errors.ErrTimeoutNotFloat
+	}
+	if secs < 0 {
+		return 0, errors.ErrTimeoutNegative
+	}
+	// The timeout must fit in a duration
+	if secs >= math.MaxInt64/float64(time.Second) {
+		return 0, errors.ErrTimeoutNotFloat
+	}
+
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// LMPOP numkeys key [key ...] <LEFT | RIGHT> [COUNT count]
+func handleLmpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys, side, count, err := parseMpopArgs(cmd[1:])
+	if err != nil {
+		return "", err
+	}
+
+	popped, err := store.Lmpop(keys, side, count)
+	if err != nil {
+		return "", err
+	}
+
+	return poppedListToArray(popped), nil
+}
+
+// BLMPOP timeout numkeys key [key ...] <LEFT | RIGHT> [COUNT count]
+func handleBlmpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	timeout, err := parseTimeout(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	keys, side, count, err := parseMpopArgs(cmd[2:])
+	if err != nil {
+		return "", err
+	}
+
+	popped, err := store.Bpop(keys, side, count, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	return poppedListToArray(popped), nil
+}
+
+// parseMpopArgs parses the numkeys key [key ...] <LEFT | RIGHT> [COUNT count] arguments of the
+// multi-key pops.
+func parseMpopArgs(args []*protocol.RespVal) ([]string, storage.ListSide, int, error) {
+	keys, args, err := parseNumKeys(args, errors.ErrNumkeys, errors.ErrSyntax)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	// The side must follow the keys
+	if len(args) == 0 {
+		return nil, 0, 0, errors.ErrSyntax
+	}
+
+	side, err := parseListSide(args[0].BulkStrs())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	count := 1
+	switch {
+	case len(args) == 1:
+	case len(args) == 3 && strings.ToUpper(args[1].BulkStrs()) == "COUNT":
+		count, err = strconv.Atoi(args[2].BulkStrs())
+		if err != nil || count <= 0 {
+			return nil, 0, 0, errors.ErrCountNotPositive
+		}
+	default:
+		return nil, 0, 0, errors.ErrSyntax
+	}
+
+	return keys, side, count, nil
+}
+
+// poppedListToArray returns the reply of the key and the elements popped from its list, or the null
+// array if nothing was popped.
+func poppedListToArray(popped *storage.PoppedList) string {
+	if popped == nil {
+		return protocol.ToArray(nil)
+	}
+
+	return protocol.ToArray([]string{
+		protocol.ToBulkStr(popped.Key),
+		protocol.ToArray(protocol.ToBulkStrArr(popped.Vals)),
+	})
 }
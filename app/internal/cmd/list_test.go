@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func TestParseMpopArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantKeys  []string
+		wantSide  storage.ListSide
+		wantCount int
+		wantErr   error
+	}{
+		{name: "single key", args: []string{"1", "a", "LEFT"}, wantKeys: []string{"a"}, wantSide: storage.ListLeft, wantCount: 1},
+		{name: "keys with count", args: []string{"2", "a", "b", "right", "COUNT", "3"}, wantKeys: []string{"a", "b"}, wantSide: storage.ListRight, wantCount: 3},
+		{name: "max count", args: []string{"1", "a", "LEFT", "COUNT", "9223372036854775807"}, wantKeys: []string{"a"}, wantSide: storage.ListLeft, wantCount: 1<<63 - 1},
+		{name: "zero numkeys", args: []string{"0", "a", "LEFT"}, wantErr: errors.ErrNumkeys},
+		{name: "numkeys past the arguments", args: []string{"3", "a", "LEFT"}, wantErr: errors.ErrSyntax},
+		{name: "numkeys covering the side", args: []string{"2", "a", "LEFT"}, wantErr: errors.ErrSyntax},
+		{name: "unknown side", args: []string{"1", "a", "UP"}, wantErr: errors.ErrSyntax},
+		{name: "missing side", args: []string{"1", "a"}, wantErr: errors.ErrSyntax},
+		{name: "zero count", args: []string{"1", "a", "LEFT", "COUNT", "0"}, wantErr: errors.ErrCountNotPositive},
+		{name: "min count", args: []string{"1", "a", "LEFT", "COUNT", "-9223372036854775808"}, wantErr: errors.ErrCountNotPositive},
+		{name: "count without value", args: []string{"1", "a", "LEFT", "COUNT"}, wantErr: errors.ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, side, count, err := parseMpopArgs(bulkStrs(tt.args...))
+			if err != tt.wantErr {
+				t.Fatalf("parseMpopArgs() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(keys, tt.wantKeys) || side != tt.wantSide || count != tt.wantCount {
+				t.Errorf("parseMpopArgs() = %v, %v, %d, want %v, %v, %d", keys, side, count, tt.wantKeys, tt.wantSide, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    time.Duration
+		wantErr error
+	}{
+		{name: "zero", arg: "0", want: 0},
+		{name: "fractional", arg: "0.25", want: 250 * time.Millisecond},
+		{name: "large", arg: "1e9", want: 1e9 * time.Second},
+		{name: "overflows a duration", arg: "1e12", wantErr: errors.ErrTimeoutNotFloat},
+		{name: "max float", arg: "1.7976931348623157e308", wantErr: errors.ErrTimeoutNotFloat},
+		{name: "infinite", arg: "inf", wantErr: errors.ErrTimeoutNotFloat},
+		{name: "not a float", arg: "abc", wantErr: errors.ErrTimeoutNotFloat},
+		{name: "negative", arg: "-1", wantErr: errors.ErrTimeoutNegative},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeout(tt.arg)
+			if err != tt.wantErr {
+				t.Fatalf("parseTimeout() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parseTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLmpop(t *testing.T) {
+	store := storage.NewMem()
+	if _, err := store.Rpush("b", "1", "2", "3", "4", "5"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	if _, err := store.Rpush("c", "x"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+
+	popped := func(key string, vals ...string) string {
+		return protocol.ToArray([]string{protocol.ToBulkStr(key), protocol.ToArray(protocol.ToBulkStrArr(toAnys(vals)))})
+	}
+
+	steps := []struct {
+		args []string
+		want string
+	}{
+		// The first non-empty list is popped from
+		{args: []string{"3", "a", "b", "c", "LEFT"}, want: popped("b", "1")},
+		{args: []string{"2", "a", "b", "RIGHT", "COUNT", "2"}, want: popped("b", "5", "4")},
+		{args: []string{"1", "b", "LEFT", "COUNT", "10"}, want: popped("b", "2", "3")},
+		// The emptied list is removed
+		{args: []string{"2", "b", "c", "left", "count", "3"}, want: popped("c", "x")},
+		{args: []string{"2", "b", "c", "RIGHT"}, want: protocol.ToArray(nil)},
+	}
+
+	for _, step := range steps {
+		got, err := handleLmpop(bulkStrs(append([]string{"LMPOP"}, step.args...)...), store)
+		if err != nil {
+			t.Fatalf("LMPOP %v error = %v", step.args, err)
+		}
+		if got != step.want {
+			t.Errorf("LMPOP %v = %q, want %q", step.args, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+// SADD key member [member ...]
+func handleSadd(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	added, err := store.SAdd(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(added)), nil
+}
+
+func handleSrem(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	removed, err := store.SRem(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleSmembers(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	members, err := store.SMembers(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToArray(protocol.ToBulkStrArr(members)), nil
+}
+
+func handleSismember(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	found, err := store.SIsMember(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(found)), nil
+}
+
+// SMISMEMBER key member [member ...]
+func handleSmismember(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	found, err := store.SMIsMember(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	resps := make([]string, len(found))
+	for i, ok := range found {
+		resps[i] = protocol.ToIntegers(boolToInt(ok))
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+func handleScard(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	n, err := store.SCard(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// SPOP key [count]
+func handleSpop(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 && len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	if len(cmd) == 2 {
+		return randomMember(store.SPop(cmd[1].BulkStrs(), 1))
+	}
+
+	count, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+	if count < 0 {
+		return "", errors.ErrNotPositive
+	}
+
+	popped, err := store.SPop(cmd[1].BulkStrs(), count)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToArray(protocol.ToBulkStrArr(popped)), nil
+}
+
+// SRANDMEMBER key [count]
+func handleSrandmember(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 && len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	if len(cmd) == 2 {
+		return randomMember(store.SRandMember(cmd[1].BulkStrs(), 1))
+	}
+
+	count, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	picked, err := store.SRandMember(cmd[1].BulkStrs(), count)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToArray(protocol.ToBulkStrArr(picked)), nil
+}
+
+// randomMember returns the reply of the single random member picked out of a set, if any.
+func randomMember(members []any, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if len(members) == 0 {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(members[0]), nil
+}
+
+// SMOVE source destination member
+func handleSmove(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	moved, err := store.SMove(cmd[1].BulkStrs(), cmd[2].BulkStrs(), cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(moved)), nil
+}
+
+// SINTER key [key ...]
+func handleSinter(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOp(cmd, store, storage.SetOpInter)
+}
+
+func handleSunion(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOp(cmd, store, storage.SetOpUnion)
+}
+
+func handleSdiff(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOp(cmd, store, storage.SetOpDiff)
+}
+
+// setOp handles the commands replying with the result of a set operation between sets.
+func setOp(cmd []*protocol.RespVal, store *storage.Mem, op storage.SetOp) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	members, err := store.SetOp(op, toStrs(cmd[1:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToArray(protocol.ToBulkStrArr(members)), nil
+}
+
+// SINTERSTORE destination key [key ...]
+func handleSinterstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOpStore(cmd, store, storage.SetOpInter)
+}
+
+func handleSunionstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOpStore(cmd, store, storage.SetOpUnion)
+}
+
+func handleSdiffstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setOpStore(cmd, store, storage.SetOpDiff)
+}
+
+// setOpStore handles the commands storing the result of a set operation between sets.
+func setOpStore(cmd []*protocol.RespVal, store *storage.Mem, op storage.SetOp) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	n, err := store.SetOpStore(op, cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// SINTERCARD numkeys key [key ...] [LIMIT limit]
+func handleSintercard(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys, args, err := parseNumKeys(cmd[1:], errors.ErrNumkeys, errors.ErrNumkeysTooMany)
+	if err != nil {
+		return "", err
+	}
+
+	var limit int
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && strings.ToUpper(args[0].BulkStrs()) == "LIMIT":
+		limit, err = strconv.Atoi(args[1].BulkStrs())
+		if err != nil {
+			return "", errors.ErrNotANumericValue
+		}
+		if limit < 0 {
+			return "", errors.ErrLimitNegative
+		}
+	default:
+		return "", errors.ErrSyntax
+	}
+
+	n, err := store.SInterCard(limit, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"strconv"
+	"testing"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func TestSintercard(t *testing.T) {
+	store := storage.NewMem()
+	var a, b []string
+	for i := range 1000 {
+		a = append(a, strconv.Itoa(i))
+		b = append(b, strconv.Itoa(i+900))
+	}
+	store.SAdd("a", a...)
+	store.SAdd("b", b...)
+	store.SAdd("c", "x")
+	store.SetWithOpts("str", "v", storage.SetOpts{})
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    int64
+		wantErr error
+	}{
+		{name: "no limit", args: []string{"2", "a", "b"}, want: 100},
+		{name: "zero limit", args: []string{"2", "a", "b", "LIMIT", "0"}, want: 100},
+		// The count stops at the limit
+		{name: "limit below the count", args: []string{"2", "a", "b", "LIMIT", "10"}, want: 10},
+		{name: "limit of one", args: []string{"2", "b", "a", "limit", "1"}, want: 1},
+		{name: "limit equal to the count", args: []string{"2", "a", "b", "LIMIT", "100"}, want: 100},
+		{name: "limit above the count", args: []string{"2", "a", "b", "LIMIT", "9223372036854775807"}, want: 100},
+		{name: "single set", args: []string{"1", "a", "LIMIT", "999"}, want: 999},
+		{name: "disjoint sets", args: []string{"2", "a", "c", "LIMIT", "5"}, want: 0},
+		{name: "missing key", args: []string{"3", "a", "b", "missing", "LIMIT", "5"}, want: 0},
+		{name: "wrong type", args: []string{"2", "a", "str", "LIMIT", "5"}, wantErr: errors.ErrWrongType},
+		{name: "negative limit", args: []string{"1", "a", "LIMIT", "-1"}, wantErr: errors.ErrLimitNegative},
+		{name: "limit not a number", args: []string{"1", "a", "LIMIT", "x"}, wantErr: errors.ErrNotANumericValue},
+		{name: "unknown option", args: []string{"1", "a", "COUNT", "1"}, wantErr: errors.ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handleSintercard(bulkStrs(append([]string{"SINTERCARD"}, tt.args...)...), store)
+			if err != tt.wantErr {
+				t.Fatalf("SINTERCARD error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != protocol.ToIntegers(tt.want) {
+				t.Errorf("SINTERCARD = %q, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -16,29 +16,106 @@ func handleSet(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", errors.ErrInvalidCmd
 	}
 
-	var exp time.Duration
-	if len(cmd) > 3 { // Optional exp arg is present
-		if len(cmd) != 5 {
-			return "", errors.ErrInvalidCmd
-		}
+	var (
+		opts      storage.SetOpts
+		hasExpiry bool
+	)
+	for i := 3; i < len(cmd); i++ {
+		switch opt := strings.ToUpper(cmd[i].BulkStrs()); opt {
+		case "NX":
+			if opts.XX {
+				return "", errors.ErrSyntax
+			}
+			opts.NX = true
 
-		flag := strings.ToUpper(cmd[3].BulkStrs())
-		dur, err := strconv.ParseInt(cmd[4].BulkStrs(), 10, 64)
-		if err != nil {
-			return "", fmt.Errorf("invalid expiry value")
-		}
+		case "XX":
+			if opts.NX {
+				return "", errors.ErrSyntax
+			}
+			opts.XX = true
+
+		case "GET":
+			opts.Get = true
+
+		case "KEEPTTL":
+			if hasExpiry {
+				return "", errors.ErrSyntax
+			}
+			opts.KeepTTL = true
+
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpiry || opts.KeepTTL || i+1 >= len(cmd) {
+				return "", errors.ErrSyntax
+			}
+			i++
+
+			at, err := parseExpireAt(opt, cmd[i], "set")
+			if err != nil {
+				return "", err
+			}
+
+			opts.ExpireAt = at
+			hasExpiry = true
 
-		switch flag {
-		case "EX":
-			exp = time.Duration(dur * int64(time.Second))
-		case "PX":
-			exp = time.Duration(dur * int64(time.Millisecond))
 		default:
-			return "", fmt.Errorf("invalid expiry flag")
+			return "", errors.ErrSyntax
 		}
 	}
 
-	store.Set(cmd[1].BulkStrs(), cmd[2].BulkStrs(), exp)
+	old, set, err := store.SetWithOpts(cmd[1].BulkStrs(), cmd[2].BulkStrs(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Get {
+		if old == nil {
+			return protocol.ToNulls(), nil
+		}
+		return protocol.ToBulkStr(old), nil
+	}
+	if !set {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func handleSetnx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	_, set, err := store.SetWithOpts(cmd[1].BulkStrs(), cmd[2].BulkStrs(), storage.SetOpts{NX: true})
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(boolToInt(set)), nil
+}
+
+func handleSetex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setWithExpiry(cmd, store, "EX", "setex")
+}
+
+func handlePsetex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return setWithExpiry(cmd, store, "PX", "psetex")
+}
+
+// setWithExpiry sets the value of the "key expiry value" arguments, the expiry being in the unit of the given option.
+func setWithExpiry(cmd []*protocol.RespVal, store *storage.Mem, opt, cmdName string) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	at, err := parseExpireAt(opt, cmd[2], cmdName)
+	if err != nil {
+		return "", err
+	}
+
+	if _, _, err := store.SetWithOpts(cmd[1].BulkStrs(), cmd[3].BulkStrs(), storage.SetOpts{ExpireAt: at}); err != nil {
+		return "", err
+	}
+
 	return protocol.ToSimpleStr("OK"), nil
 }
 
@@ -47,22 +124,347 @@ func handleGet(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 		return "", errors.ErrInvalidCmd
 	}
 
-	if val, ok := store.Get(cmd[1].BulkStrs()); !ok {
+	if val, ok, err := store.Get(cmd[1].BulkStrs()); err != nil {
+		return "", err
+	} else if !ok {
 		return protocol.ToNulls(), nil
 	} else {
 		return protocol.ToBulkStr(val), nil
 	}
 }
 
-func handleIncr(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+func handleGetex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
 	if len(cmd) < 2 {
 		return "", errors.ErrInvalidCmd
 	}
 
-	val, err := store.Incr(cmd[1].BulkStrs())
+	var (
+		expireAt time.Time
+		persist  bool
+	)
+	if len(cmd) > 2 {
+		switch opt := strings.ToUpper(cmd[2].BulkStrs()); opt {
+		case "PERSIST":
+			if len(cmd) != 3 {
+				return "", errors.ErrSyntax
+			}
+			persist = true
+
+		case "EX", "PX", "EXAT", "PXAT":
+			if len(cmd) != 4 {
+				return "", errors.ErrSyntax
+			}
+
+			at, err := parseExpireAt(opt, cmd[3], "getex")
+			if err != nil {
+				return "", err
+			}
+			expireAt = at
+
+		default:
+			return "", errors.ErrSyntax
+		}
+	}
+
+	val, ok, err := store.GetEx(cmd[1].BulkStrs(), expireAt, persist)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleGetdel(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	val, ok, err := store.GetDel(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleIncr(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	return incrBy(store, cmd[1].BulkStrs(), 1)
+}
+
+func handleDecr(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	return incrBy(store, cmd[1].BulkStrs(), -1)
+}
+
+func handleIncrby(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	delta, err := strconv.ParseInt(cmd[2].BulkStrs(), 10, 64)
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	return incrBy(store, cmd[1].BulkStrs(), delta)
+}
+
+func handleDecrby(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	delta, err := strconv.ParseInt(cmd[2].BulkStrs(), 10, 64)
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+	// The decrement can't be negated
+	if delta == math.MinInt64 {
+		return "", errors.ErrDecrOverflow
+	}
+
+	return incrBy(store, cmd[1].BulkStrs(), -delta)
+}
+
+// incrBy increments the integer value of the key by delta and returns the incremented value
+func incrBy(store *storage.Mem, key string, delta int64) (string, error) {
+	val, err := store.IncrBy(key, delta)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(val), nil
+}
+
+func handleIncrbyfloat(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	val, err := store.IncrByFloat(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleAppend(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	strLen, err := store.Append(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(strLen)), nil
+}
+
+func handleStrlen(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	strLen, err := store.Strlen(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(strLen)), nil
+}
+
+func handleGetrange(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	start, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	end, err := strconv.Atoi(cmd[3].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+
+	val, err := store.GetRange(cmd[1].BulkStrs(), start, end)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToBulkStr(val), nil
+}
+
+func handleSetrange(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	offset, err := strconv.Atoi(cmd[2].BulkStrs())
+	if err != nil {
+		return "", errors.ErrNotANumericValue
+	}
+	if offset < 0 {
+		return "", errors.ErrOffsetOutOfRange
+	}
+
+	strLen, err := store.SetRange(cmd[1].BulkStrs(), offset, cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(strLen)), nil
+}
+
+func handleLcs(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		getLen, getIdx, withMatchLen bool
+		minMatchLen                  int
+	)
+	for i := 3; i < len(cmd); i++ {
+		switch strings.ToUpper(cmd[i].BulkStrs()) {
+		case "LEN":
+			getLen = true
+		case "IDX":
+			getIdx = true
+		case "WITHMATCHLEN":
+			withMatchLen = true
+
+		case "MINMATCHLEN":
+			if i+1 >= len(cmd) {
+				return "", errors.ErrSyntax
+			}
+			i++
+
+			val, err := strconv.Atoi(cmd[i].BulkStrs())
+			if err != nil {
+				return "", errors.ErrNotANumericValue
+			}
+			minMatchLen = max(val, 0)
+
+		default:
+			return "", errors.ErrSyntax
+		}
+	}
+
+	if getLen && getIdx {
+		return "", errors.ErrLCSLenAndIdx
+	}
+
+	result, err := store.LCS(cmd[1].BulkStrs(), cmd[2].BulkStrs(), minMatchLen)
 	if err != nil {
 		return "", err
 	}
 
-	return protocol.ToNumeric(val), nil
+	if getLen {
+		return protocol.ToIntegers(int64(len(result.Seq))), nil
+	}
+	if !getIdx {
+		return protocol.ToBulkStr(result.Seq), nil
+	}
+
+	matches := make([]string, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		ranges := []string{
+			protocol.ToArray([]string{protocol.ToIntegers(int64(match.A[0])), protocol.ToIntegers(int64(match.A[1]))}),
+			protocol.ToArray([]string{protocol.ToIntegers(int64(match.B[0])), protocol.ToIntegers(int64(match.B[1]))}),
+		}
+		if withMatchLen {
+			ranges = append(ranges, protocol.ToIntegers(int64(match.Len)))
+		}
+
+		matches = append(matches, protocol.ToArray(ranges))
+	}
+
+	return protocol.ToArray([]string{
+		protocol.ToBulkStr("matches"), protocol.ToArray(matches),
+		protocol.ToBulkStr("len"), protocol.ToIntegers(int64(len(result.Seq))),
+	}), nil
+}
+
+func handleMget(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	vals := store.MGet(toStrs(cmd[1:])...)
+
+	resps := make([]string, 0, len(vals))
+	for _, val := range vals {
+		if val == nil {
+			resps = append(resps, protocol.ToNulls())
+		} else {
+			resps = append(resps, protocol.ToBulkStr(val))
+		}
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+func handleMset(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 || len(cmd)%2 != 1 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	store.MSet(toStrs(cmd[1:]), false)
+	return protocol.ToSimpleStr("OK"), nil
+}
+
+func handleMsetnx(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 || len(cmd)%2 != 1 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	set := store.MSet(toStrs(cmd[1:]), true)
+	return protocol.ToIntegers(boolToInt(set)), nil
+}
+
+// parseExpireAt parses the expiry argument of the "EX", "PX", "EXAT" or "PXAT" option into the time
+// at which the key expires. The command name is reported if the expiry is invalid.
+func parseExpireAt(opt string, arg *protocol.RespVal, cmdName string) (time.Time, error) {
+	val, err := strconv.ParseInt(arg.BulkStrs(), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.ErrNotANumericValue
+	}
+	if val <= 0 {
+		return time.Time{}, errors.ErrInvalidExpireTime(cmdName)
+	}
+
+	// Convert the expiry to the unix milliseconds, making sure it doesn't overflow
+	ms := val
+	if opt == "EX" || opt == "EXAT" {
+		if val > math.MaxInt64/1000 {
+			return time.Time{}, errors.ErrInvalidExpireTime(cmdName)
+		}
+		ms = val * 1000
+	}
+	if opt == "EX" || opt == "PX" {
+		now := time.Now().UnixMilli()
+		if ms > math.MaxInt64-now {
+			return time.Time{}, errors.ErrInvalidExpireTime(cmdName)
+		}
+		ms += now
+	}
+
+	return time.UnixMilli(ms), nil
 }
@@ -0,0 +1,705 @@
+package cmd
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+// parseScore parses the score of a sorted set member, which may be "inf" or "-inf" but not NaN.
+func parseScore(arg *protocol.RespVal) (float64, error) {
+	score, err := strconv.ParseFloat(arg.BulkStrs(), 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, errors.ErrNotAValidFloat
+	}
+
+	return score, nil
+}
+
+// formatScore formats the score of a sorted set member like Redis: the integers are formatted as
+// such, and the other values with the fewest digits needed to parse them back, in scientific notation
+// if they're very large or small.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	case score == 0 && math.Signbit(score):
+		return "-0"
+	case score >= -math.MaxInt64/2 && score <= math.MaxInt64/2 && score == math.Trunc(score):
+		return strconv.FormatInt(int64(score), 10)
+	}
+
+	// Split the shortest representation, formatted as "d.ddde±dd", into its digits and the exponent
+	// of the first digit
+	s := strconv.FormatFloat(score, 'e', -1, 64)
+	neg := s[0] == '-'
+	if neg {
+		s = s[1:]
+	}
+	mantissa, expStr, _ := strings.Cut(s, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(expStr)
+
+	// The value is the digits multiplied by 10^k
+	k := exp - (len(digits) - 1)
+	absExp := max(exp, -exp)
+
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+
+	switch {
+	case k >= 0 && absExp < len(digits)+7:
+		b.WriteString(digits)
+		b.WriteString(strings.Repeat("0", k))
+
+	case k < 0 && (k > -7 || absExp < 4):
+		if point := len(digits) + k; point > 0 {
+			b.WriteString(digits[:point])
+			b.WriteByte('.')
+			b.WriteString(digits[point:])
+		} else {
+			b.WriteString("0.")
+			b.WriteString(strings.Repeat("0", -point))
+			b.WriteString(digits)
+		}
+
+	default:
+		b.WriteByte(digits[0])
+		if len(digits) > 1 {
+			b.WriteByte('.')
+			b.WriteString(digits[1:])
+		}
+		b.WriteByte('e')
+		if exp < 0 {
+			b.WriteByte('-')
+		} else {
+			b.WriteByte('+')
+		}
+		b.WriteString(strconv.Itoa(absExp))
+	}
+
+	return b.String()
+}
+
+// ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...]
+func handleZadd(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	var (
+		opts     storage.ZAddOpts
+		ch, incr bool
+		idx      = 2
+	)
+options:
+	for ; idx < len(cmd); idx++ {
+		switch strings.ToUpper(cmd[idx].BulkStrs()) {
+		case "NX":
+			opts.NX = true
+		case "XX":
+			opts.XX = true
+		case "GT":
+			opts.GT = true
+		case "LT":
+			opts.LT = true
+		case "CH":
+			ch = true
+		case "INCR":
+			incr = true
+		default:
+			break options
+		}
+	}
+
+	args := cmd[idx:]
+	if len(args) == 0 || len(args)%2 != 0 {
+		return "", errors.ErrSyntax
+	}
+	if opts.NX && opts.XX {
+		return "", errors.ErrZaddNXAndXX
+	}
+	if (opts.GT && opts.NX) || (opts.LT && opts.NX) || (opts.GT && opts.LT) {
+		return "", errors.ErrZaddGTLTAndNX
+	}
+	if incr && len(args) > 2 {
+		return "", errors.ErrZaddIncrPair
+	}
+
+	elems := make([]storage.ScoredMember, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		score, err := parseScore(args[i])
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, storage.ScoredMember{Member: args[i+1].BulkStrs(), Score: score})
+	}
+
+	if incr {
+		return zincrby(store, cmd[1].BulkStrs(), opts, elems[0])
+	}
+
+	added, updated, err := store.ZAdd(cmd[1].BulkStrs(), opts, elems...)
+	if err != nil {
+		return "", err
+	}
+	if ch {
+		added += updated
+	}
+
+	return protocol.ToIntegers(int64(added)), nil
+}
+
+// ZINCRBY key increment member
+func handleZincrby(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	delta, err := parseScore(cmd[2])
+	if err != nil {
+		return "", err
+	}
+
+	return zincrby(store, cmd[1].BulkStrs(), storage.ZAddOpts{}, storage.ScoredMember{Member: cmd[3].BulkStrs(), Score: delta})
+}
+
+// zincrby increments the score of the member by its given score, replying with the resulting score,
+// or nil if the options prevented the increment.
+func zincrby(store *storage.Mem, key string, opts storage.ZAddOpts, elem storage.ScoredMember) (string, error) {
+	score, ok, err := store.ZIncrBy(key, opts, elem.Score, elem.Member)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(formatScore(score)), nil
+}
+
+func handleZrem(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	removed, err := store.ZRem(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(removed)), nil
+}
+
+func handleZscore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	score, ok, err := store.ZScore(cmd[1].BulkStrs(), cmd[2].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return protocol.ToNulls(), nil
+	}
+
+	return protocol.ToBulkStr(formatScore(score)), nil
+}
+
+// ZMSCORE key member [member ...]
+func handleZmscore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	scores, err := store.ZMScore(cmd[1].BulkStrs(), toStrs(cmd[2:])...)
+	if err != nil {
+		return "", err
+	}
+
+	resps := make([]string, len(scores))
+	for i, score := range scores {
+		if score == nil {
+			resps[i] = protocol.ToNulls()
+		} else {
+			resps[i] = protocol.ToBulkStr(formatScore(score.(float64)))
+		}
+	}
+
+	return protocol.ToArray(resps), nil
+}
+
+func handleZcard(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 2 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	n, err := store.ZCard(cmd[1].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// ZRANK key member [WITHSCORE]
+func handleZrank(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrank(cmd, store, false)
+}
+
+func handleZrevrank(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrank(cmd, store, true)
+}
+
+// zrank handles the commands getting the rank of a sorted set member, counting from the highest
+// score if rev is true.
+func zrank(cmd []*protocol.RespVal, store *storage.Mem, rev bool) (string, error) {
+	if len(cmd) != 3 && len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	withScore := len(cmd) == 4
+	if withScore && strings.ToUpper(cmd[3].BulkStrs()) != "WITHSCORE" {
+		return "", errors.ErrSyntax
+	}
+
+	rank, score, ok, err := store.ZRank(cmd[1].BulkStrs(), cmd[2].BulkStrs(), rev)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case !ok && withScore:
+		return protocol.ToArray(nil), nil
+	case !ok:
+		return protocol.ToNulls(), nil
+	case withScore:
+		return protocol.ToArray([]string{
+			protocol.ToIntegers(int64(rank)),
+			protocol.ToBulkStr(formatScore(score)),
+		}), nil
+	default:
+		return protocol.ToIntegers(int64(rank)), nil
+	}
+}
+
+// ZRANGE key start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
+func handleZrange(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByRank, false, true)
+}
+
+func handleZrevrange(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByRank, true, false)
+}
+
+func handleZrangebyscore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByScore, false, false)
+}
+
+func handleZrevrangebyscore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByScore, true, false)
+}
+
+func handleZrangebylex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByLex, false, false)
+}
+
+func handleZrevrangebylex(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zrange(cmd, store, storage.ZRangeByLex, true, false)
+}
+
+// zrange handles the variants of the "ZRANGE" command. The legacy variants fix the kind and the
+// direction of the range, which only "ZRANGE" takes as options if unified is true.
+func zrange(cmd []*protocol.RespVal, store *storage.Mem, by storage.ZRangeBy, rev, unified bool) (string, error) {
+	if len(cmd) < 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	opts, withScores, err := parseZrangeOpts(cmd[2:], by, rev, unified, false)
+	if err != nil {
+		return "", err
+	}
+
+	elems, err := store.ZRange(cmd[1].BulkStrs(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	return scoredMembersToArray(elems, withScores), nil
+}
+
+// ZRANGESTORE dst src min max [BYSCORE|BYLEX] [REV] [LIMIT offset count]
+func handleZrangestore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 5 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	opts, _, err := parseZrangeOpts(cmd[3:], storage.ZRangeByRank, false, true, true)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := store.ZRangeStore(cmd[1].BulkStrs(), cmd[2].BulkStrs(), opts)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// parseZrangeOpts parses the bounds of the range followed by the options of the "ZRANGE" command.
+// The kind and direction of the range can only be changed by the options if unified is true, and
+// "WITHSCORES" isn't accepted if the range is stored. It returns whether the scores are requested.
+func parseZrangeOpts(args []*protocol.RespVal, by storage.ZRangeBy, rev, unified, storing bool) (storage.ZRangeOpts, bool, error) {
+	var (
+		opts                 = storage.ZRangeOpts{Count: -1}
+		withScores, hasLimit bool
+		err                  error
+	)
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i].BulkStrs()); {
+		case opt == "WITHSCORES" && !storing:
+			withScores = true
+
+		case opt == "LIMIT" && i+2 < len(args):
+			if opts.Offset, err = strconv.Atoi(args[i+1].BulkStrs()); err != nil {
+				return opts, false, errors.ErrNotANumericValue
+			}
+			if opts.Count, err = strconv.Atoi(args[i+2].BulkStrs()); err != nil {
+				return opts, false, errors.ErrNotANumericValue
+			}
+			hasLimit = true
+			i += 2
+
+		case opt == "REV" && unified && !rev:
+			rev = true
+
+		case opt == "BYSCORE" && unified && by == storage.ZRangeByRank:
+			by = storage.ZRangeByScore
+
+		case opt == "BYLEX" && unified && by == storage.ZRangeByRank:
+			by = storage.ZRangeByLex
+
+		default:
+			return opts, false, errors.ErrSyntax
+		}
+	}
+
+	if hasLimit && by == storage.ZRangeByRank {
+		return opts, false, errors.ErrZrangeLimit
+	}
+	if withScores && by == storage.ZRangeByLex {
+		return opts, false, errors.ErrZrangeWithscores
+	}
+
+	// The reversed ranges by score or lex start from the maximum
+	minArg, maxArg := args[0].BulkStrs(), args[1].BulkStrs()
+	if rev && by != storage.ZRangeByRank {
+		minArg, maxArg = maxArg, minArg
+	}
+
+	opts.By, opts.Rev = by, rev
+	switch by {
+	case storage.ZRangeByRank:
+		opts.Start, err = strconv.Atoi(minArg)
+		if err == nil {
+			opts.Stop, err = strconv.Atoi(maxArg)
+		}
+		if err != nil {
+			return opts, false, errors.ErrNotANumericValue
+		}
+
+	case storage.ZRangeByScore:
+		opts.Score, err = parseScoreRange(minArg, maxArg)
+
+	case storage.ZRangeByLex:
+		opts.Lex, err = parseLexRange(minArg, maxArg)
+	}
+
+	return opts, withScores, err
+}
+
+// parseScoreRange parses the bounds of a range of scores, "(" excluding the score which follows.
+func parseScoreRange(minArg, maxArg string) (storage.ScoreRange, error) {
+	var (
+		r   storage.ScoreRange
+		err error
+	)
+	if r.Min, r.MinEx, err = parseScoreBound(minArg); err != nil {
+		return r, err
+	}
+	if r.Max, r.MaxEx, err = parseScoreBound(maxArg); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// parseScoreBound parses a bound of a range of scores, returning whether it's excluded.
+func parseScoreBound(arg string) (float64, bool, error) {
+	ex := strings.HasPrefix(arg, "(")
+	if ex {
+		arg = arg[1:]
+	}
+
+	score, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false, errors.ErrMinMaxNotFloat
+	}
+
+	return score, ex, nil
+}
+
+// parseLexRange parses the bounds of a range of members, each being either "-" or "+", or a member
+// prefixed by "[" to include it or "(" to exclude it.
+func parseLexRange(minArg, maxArg string) (storage.LexRange, error) {
+	var (
+		r      storage.LexRange
+		ok     bool
+		minInf bool
+		maxInf bool
+	)
+	if r.Min, r.MinEx, minInf, ok = parseLexBound(minArg); !ok {
+		return r, errors.ErrMinMaxNotLex
+	}
+	if r.Max, r.MaxEx, maxInf, ok = parseLexBound(maxArg); !ok {
+		return r, errors.ErrMinMaxNotLex
+	}
+
+	// An infinite bound on the wrong side makes the range empty
+	switch {
+	case minInf && r.Min == "+", maxInf && r.Max == "-":
+		r.NoMin, r.NoMax = false, false
+		r.Min, r.Max, r.MinEx = "", "", true
+	default:
+		r.NoMin = minInf && r.Min == "-"
+		r.NoMax = maxInf && r.Max == "+"
+	}
+
+	return r, nil
+}
+
+// parseLexBound parses a bound of a range of members. It returns the member, whether it's excluded
+// and whether the bound is "-" or "+" instead, in which case the member is that sign.
+func parseLexBound(arg string) (string, bool, bool, bool) {
+	switch {
+	case arg == "-" || arg == "+":
+		return arg, false, true, true
+	case strings.HasPrefix(arg, "("):
+		return arg[1:], true, false, true
+	case strings.HasPrefix(arg, "["):
+		return arg[1:], false, false, true
+	default:
+		return "", false, false, false
+	}
+}
+
+// scoredMembersToArray returns the array reply of the sorted set members, followed each by its score
+// if withScores is true.
+func scoredMembersToArray(elems []storage.ScoredMember, withScores bool) string {
+	resps := make([]string, 0, len(elems)*2)
+	for _, e := range elems {
+		resps = append(resps, protocol.ToBulkStr(e.Member))
+		if withScores {
+			resps = append(resps, protocol.ToBulkStr(formatScore(e.Score)))
+		}
+	}
+
+	return protocol.ToArray(resps)
+}
+
+// ZCOUNT key min max
+func handleZcount(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	r, err := parseScoreRange(cmd[2].BulkStrs(), cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	n, err := store.ZCount(cmd[1].BulkStrs(), r)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// ZLEXCOUNT key min max
+func handleZlexcount(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) != 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	r, err := parseLexRange(cmd[2].BulkStrs(), cmd[3].BulkStrs())
+	if err != nil {
+		return "", err
+	}
+
+	n, err := store.ZLexCount(cmd[1].BulkStrs(), r)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// ZUNION numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM|MIN|MAX] [WITHSCORES]
+func handleZunion(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOp(cmd, store, storage.SetOpUnion, "zunion")
+}
+
+func handleZinter(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOp(cmd, store, storage.SetOpInter, "zinter")
+}
+
+// ZDIFF numkeys key [key ...] [WITHSCORES]
+func handleZdiff(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOp(cmd, store, storage.SetOpDiff, "zdiff")
+}
+
+// zsetOp handles the commands replying with the result of a set operation between sorted sets.
+func zsetOp(cmd []*protocol.RespVal, store *storage.Mem, op storage.SetOp, cmdName string) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys, opts, withScores, err := parseZsetOpArgs(cmd[1:], op, cmdName, false)
+	if err != nil {
+		return "", err
+	}
+
+	elems, err := store.ZSetOp(op, opts, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	return scoredMembersToArray(elems, withScores), nil
+}
+
+// ZUNIONSTORE destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM|MIN|MAX]
+func handleZunionstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOpStore(cmd, store, storage.SetOpUnion, "zunionstore")
+}
+
+func handleZinterstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOpStore(cmd, store, storage.SetOpInter, "zinterstore")
+}
+
+func handleZdiffstore(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	return zsetOpStore(cmd, store, storage.SetOpDiff, "zdiffstore")
+}
+
+// zsetOpStore handles the commands storing the result of a set operation between sorted sets.
+func zsetOpStore(cmd []*protocol.RespVal, store *storage.Mem, op storage.SetOp, cmdName string) (string, error) {
+	if len(cmd) < 4 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys, opts, _, err := parseZsetOpArgs(cmd[2:], op, cmdName, true)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := store.ZSetOpStore(op, opts, cmd[1].BulkStrs(), keys...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
+
+// parseZsetOpArgs parses the number of keys, the keys and the options of a set operation between
+// sorted sets. "WEIGHTS" and "AGGREGATE" aren't accepted by the difference, and "WITHSCORES" isn't
+// accepted if the result is stored. It returns whether the scores are requested.
+func parseZsetOpArgs(args []*protocol.RespVal, op storage.SetOp, cmdName string, storing bool) ([]string, storage.ZSetOpOpts, bool, error) {
+	var opts storage.ZSetOpOpts
+
+	keys, args, err := parseNumKeys(args, errors.ErrInputKeyNeeded(cmdName), errors.ErrSyntax)
+	if err != nil {
+		return nil, opts, false, err
+	}
+	numKeys := len(keys)
+
+	withScores := false
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i].BulkStrs()); {
+		case opt == "WEIGHTS" && op != storage.SetOpDiff && len(args)-i-1 >= numKeys:
+			opts.Weights = make([]float64, numKeys)
+			for j := range opts.Weights {
+				w, err := strconv.ParseFloat(args[i+1+j].BulkStrs(), 64)
+				if err != nil || math.IsNaN(w) {
+					return nil, opts, false, errors.ErrWeightNotFloat
+				}
+				opts.Weights[j] = w
+			}
+			i += numKeys
+
+		case opt == "AGGREGATE" && op != storage.SetOpDiff && i+1 < len(args):
+			switch strings.ToUpper(args[i+1].BulkStrs()) {
+			case "SUM":
+				opts.Aggregate = storage.ZAggregateSum
+			case "MIN":
+				opts.Aggregate = storage.ZAggregateMin
+			case "MAX":
+				opts.Aggregate = storage.ZAggregateMax
+			default:
+				return nil, opts, false, errors.ErrSyntax
+			}
+			i++
+
+		case opt == "WITHSCORES" && !storing:
+			withScores = true
+
+		default:
+			return nil, opts, false, errors.ErrSyntax
+		}
+	}
+
+	return keys, opts, withScores, nil
+}
+
+// ZINTERCARD numkeys key [key ...] [LIMIT limit]
+func handleZintercard(cmd []*protocol.RespVal, store *storage.Mem) (string, error) {
+	if len(cmd) < 3 {
+		return "", errors.ErrInvalidCmd
+	}
+
+	keys, args, err := parseNumKeys(cmd[1:], errors.ErrNumkeys, errors.ErrNumkeysTooMany)
+	if err != nil {
+		return "", err
+	}
+
+	var limit int
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && strings.ToUpper(args[0].BulkStrs()) == "LIMIT":
+		limit, err = strconv.Atoi(args[1].BulkStrs())
+		if err != nil {
+			return "", errors.ErrNotANumericValue
+		}
+		if limit < 0 {
+			return "", errors.ErrLimitNegative
+		}
+	default:
+		return "", errors.ErrSyntax
+	}
+
+	n, err := store.ZInterCard(limit, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	return protocol.ToIntegers(int64(n)), nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"gokv/app/internal/errors"
+	"gokv/app/internal/protocol"
+	"gokv/app/internal/storage"
+)
+
+func TestParseZsetOpArgs(t *testing.T) {
+	tests := []struct {
+		name           string
+		diff           bool
+		storing        bool
+		args           []string
+		wantKeys       []string
+		wantOpts       storage.ZSetOpOpts
+		wantWithScores bool
+		wantErr        error
+	}{
+		{name: "keys", args: []string{"2", "a", "b"}, wantKeys: []string{"a", "b"}},
+		{
+			name:           "all options",
+			args:           []string{"2", "a", "b", "WEIGHTS", "2", "-inf", "AGGREGATE", "max", "WITHSCORES"},
+			wantKeys:       []string{"a", "b"},
+			wantOpts:       storage.ZSetOpOpts{Weights: []float64{2, math.Inf(-1)}, Aggregate: storage.ZAggregateMax},
+			wantWithScores: true,
+		},
+		{name: "zero numkeys", args: []string{"0", "a"}, wantErr: errors.ErrInputKeyNeeded("zunion")},
+		{name: "numkeys past the arguments", args: []string{"2", "a"}, wantErr: errors.ErrSyntax},
+		{name: "missing weights", args: []string{"2", "a", "b", "WEIGHTS", "1"}, wantErr: errors.ErrSyntax},
+		{name: "weight not a float", args: []string{"1", "a", "WEIGHTS", "nan"}, wantErr: errors.ErrWeightNotFloat},
+		{name: "unknown aggregate", args: []string{"1", "a", "AGGREGATE", "AVG"}, wantErr: errors.ErrSyntax},
+		{name: "weights of a difference", diff: true, args: []string{"1", "a", "WEIGHTS", "1"}, wantErr: errors.ErrSyntax},
+		{name: "scores of a store", storing: true, args: []string{"1", "a", "WITHSCORES"}, wantErr: errors.ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := storage.SetOpUnion
+			if tt.diff {
+				op = storage.SetOpDiff
+			}
+
+			keys, opts, withScores, err := parseZsetOpArgs(bulkStrs(tt.args...), op, "zunion", tt.storing)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("parseZsetOpArgs() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(keys, tt.wantKeys) || !slices.Equal(opts.Weights, tt.wantOpts.Weights) ||
+				opts.Aggregate != tt.wantOpts.Aggregate || withScores != tt.wantWithScores {
+				t.Errorf("parseZsetOpArgs() = %v, %+v, %v, want %v, %+v, %v", keys, opts, withScores, tt.wantKeys, tt.wantOpts, tt.wantWithScores)
+			}
+		})
+	}
+}
+
+func TestZinter(t *testing.T) {
+	store := storage.NewMem()
+	zadd := func(key string, elems ...storage.ScoredMember) {
+		if _, _, err := store.ZAdd(key, storage.ZAddOpts{}, elems...); err != nil {
+			t.Fatalf("ZAdd() error = %v", err)
+		}
+	}
+	zadd("z1", storage.ScoredMember{Member: "a", Score: 1}, storage.ScoredMember{Member: "b", Score: 2}, storage.ScoredMember{Member: "c", Score: 3})
+	zadd("z2", storage.ScoredMember{Member: "b", Score: 10}, storage.ScoredMember{Member: "c", Score: 20}, storage.ScoredMember{Member: "d", Score: 30})
+	zadd("inf", storage.ScoredMember{Member: "a", Score: math.Inf(1)}, storage.ScoredMember{Member: "b", Score: 5})
+	zadd("ninf", storage.ScoredMember{Member: "a", Score: math.Inf(-1)})
+	store.SAdd("s", "c", "d")
+
+	tests := []struct {
+		name string
+		args []string
+		// want holds the members alternating with their scores
+		want []string
+	}{
+		{name: "sum", args: []string{"2", "z1", "z2", "WITHSCORES"}, want: []string{"b", "12", "c", "23"}},
+		{name: "without scores", args: []string{"2", "z2", "z1"}, want: []string{"b", "c"}},
+		{name: "weights", args: []string{"2", "z1", "z2", "WEIGHTS", "2", "3", "WITHSCORES"}, want: []string{"b", "34", "c", "66"}},
+		{name: "fractional weights", args: []string{"2", "z1", "z2", "WEIGHTS", "0.5", "0.1", "WITHSCORES"}, want: []string{"b", "2", "c", "3.5"}},
+		{name: "min", args: []string{"2", "z1", "z2", "AGGREGATE", "MIN", "WITHSCORES"}, want: []string{"b", "2", "c", "3"}},
+		{name: "max", args: []string{"2", "z1", "z2", "AGGREGATE", "max", "WITHSCORES"}, want: []string{"b", "10", "c", "20"}},
+		// The negative weight reorders the members
+		{name: "negative weight", args: []string{"2", "z1", "z2", "WEIGHTS", "1", "-1", "WITHSCORES"}, want: []string{"c", "-17", "b", "-8"}},
+		{name: "negative weight with max", args: []string{"2", "z1", "z2", "WEIGHTS", "1", "-1", "AGGREGATE", "MAX", "WITHSCORES"}, want: []string{"b", "2", "c", "3"}},
+		{name: "weights with min", args: []string{"2", "z1", "z2", "WEIGHTS", "10", "1", "AGGREGATE", "MIN", "WITHSCORES"}, want: []string{"b", "10", "c", "20"}},
+		{name: "options in any order", args: []string{"2", "z1", "z2", "WITHSCORES", "AGGREGATE", "SUM", "WEIGHTS", "1", "2"}, want: []string{"b", "22", "c", "43"}},
+		// The members of a set score 1
+		{name: "set", args: []string{"3", "z1", "z2", "s", "WITHSCORES"}, want: []string{"c", "24"}},
+		{name: "weighted set", args: []string{"2", "z2", "s", "WEIGHTS", "1", "5", "WITHSCORES"}, want: []string{"c", "25", "d", "35"}},
+		// The infinity weighted 0 scores 0, and opposite infinities sum to 0
+		{name: "infinity weighted 0", args: []string{"2", "inf", "z1", "WEIGHTS", "0", "1", "WITHSCORES"}, want: []string{"a", "1", "b", "2"}},
+		{name: "opposite infinities", args: []string{"2", "inf", "ninf", "WITHSCORES"}, want: []string{"a", "0"}},
+		{name: "infinity with min", args: []string{"2", "inf", "ninf", "AGGREGATE", "MIN", "WITHSCORES"}, want: []string{"a", "-inf"}},
+		{name: "missing key", args: []string{"2", "z1", "missing", "WITHSCORES"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handleZinter(bulkStrs(append([]string{"ZINTER"}, tt.args...)...), store)
+			if err != nil {
+				t.Fatalf("ZINTER error = %v", err)
+			}
+			if want := protocol.ToArray(protocol.ToBulkStrArr(toAnys(tt.want))); got != want {
+				t.Errorf("ZINTER = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -9,4 +9,73 @@ var (
 	ErrExecWoMulti       = fmt.Errorf("ERR EXEC without MULTI")
 	ErrDiscardWoMulti    = fmt.Errorf("ERR DISCARD without MULTI")
 	ErrInvalidCmd        = fmt.Errorf("invalid command")
+	ErrSyntax            = fmt.Errorf("ERR syntax error")
+	ErrNoSuchKey         = fmt.Errorf("ERR no such key")
+	ErrSameObject        = fmt.Errorf("ERR source and destination objects are the same")
+	ErrDBIndexOutOfRange = fmt.Errorf("ERR DB index is out of range")
+	ErrInvalidCursor     = fmt.Errorf("ERR invalid cursor")
+	ErrWrongType         = fmt.Errorf("WRONGTYPE Operation against a key holding the wrong kind of value")
+	ErrOOM               = fmt.Errorf("OOM command not allowed when used memory > 'maxmemory'.")
+	ErrIncrOverflow      = fmt.Errorf("ERR increment or decrement would overflow")
+	ErrDecrOverflow      = fmt.Errorf("ERR decrement would overflow")
+	ErrNotAValidFloat    = fmt.Errorf("ERR value is not a valid float")
+	ErrIncrNaNOrInf      = fmt.Errorf("ERR increment would produce NaN or Infinity")
+	ErrStringTooLong     = fmt.Errorf("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+	ErrOffsetOutOfRange  = fmt.Errorf("ERR offset is out of range")
+	ErrLCSTooLarge       = fmt.Errorf("ERR Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len")
+	ErrLCSLenAndIdx      = fmt.Errorf("ERR If you want both the length and indexes, please just use IDX.")
+	ErrBitOffset         = fmt.Errorf("ERR bit offset is not an integer or out of range")
+	ErrBitValue          = fmt.Errorf("ERR bit is not an integer or out of range")
+	ErrBitposBit         = fmt.Errorf("ERR The bit argument must be 1 or 0.")
+	ErrBitopNot          = fmt.Errorf("ERR BITOP NOT must be called with a single source key.")
+	ErrBitopDiff         = fmt.Errorf("ERR BITOP DIFF must be called with at least two source keys.")
+	ErrBitfieldType      = fmt.Errorf("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	ErrBitfieldOverflow  = fmt.Errorf("ERR Invalid OVERFLOW type specified")
+	ErrBitfieldRO        = fmt.Errorf("ERR BITFIELD_RO only supports the GET subcommand")
+	ErrNotHLL            = fmt.Errorf("WRONGTYPE Key is not a valid HyperLogLog string value.")
+	ErrHLLCorrupted      = fmt.Errorf("INVALIDOBJ Corrupted HLL object detected")
+	ErrIndexOutOfRange   = fmt.Errorf("ERR index out of range")
+	ErrNotPositive       = fmt.Errorf("ERR value is out of range, must be positive")
+	ErrLposRank          = fmt.Errorf("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+	ErrLposCount         = fmt.Errorf("ERR COUNT can't be negative")
+	ErrLposMaxlen        = fmt.Errorf("ERR MAXLEN can't be negative")
+	ErrTimeoutNotFloat   = fmt.Errorf("ERR timeout is not a float or out of range")
+	ErrTimeoutNegative   = fmt.Errorf("ERR timeout is negative")
+	ErrNumkeys           = fmt.Errorf("ERR numkeys should be greater than 0")
+	ErrCountNotPositive  = fmt.Errorf("ERR count should be greater than 0")
+	ErrHashValueNotInt   = fmt.Errorf("ERR hash value is not an integer")
+	ErrHashValueNotFloat = fmt.Errorf("ERR hash value is not a float")
+	ErrFieldsMissing     = fmt.Errorf("ERR Mandatory argument FIELDS is missing or not at the right position")
+	ErrNumFields         = fmt.Errorf("ERR Parameter `numFields` should be greater than 0")
+	ErrNumFieldsMismatch = fmt.Errorf("ERR The `numfields` parameter must match the number of arguments")
+	ErrExpireNegative    = fmt.Errorf("ERR invalid expire time, must be >= 0")
+	ErrNumkeysTooMany    = fmt.Errorf("ERR Number of keys can't be greater than number of args")
+	ErrLimitNegative     = fmt.Errorf("ERR LIMIT can't be negative")
+	ErrScoreNaN          = fmt.Errorf("ERR resulting score is not a number (NaN)")
+	ErrZaddNXAndXX       = fmt.Errorf("ERR XX and NX options at the same time are not compatible")
+	ErrZaddGTLTAndNX     = fmt.Errorf("ERR GT, LT, and/or NX options at the same time are not compatible")
+	ErrZaddIncrPair      = fmt.Errorf("ERR INCR option supports a single increment-element pair")
+	ErrMinMaxNotFloat    = fmt.Errorf("ERR min or max is not a float")
+	ErrMinMaxNotLex      = fmt.Errorf("ERR min or max not valid string range item")
+	ErrZrangeLimit       = fmt.Errorf("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	ErrZrangeWithscores  = fmt.Errorf("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+	ErrWeightNotFloat    = fmt.Errorf("ERR weight value is not a float")
+
+	ErrInvalidBulkLength      = fmt.Errorf("ERR Protocol error: invalid bulk length")
+	ErrInvalidMultibulkLength = fmt.Errorf("ERR Protocol error: invalid multibulk length")
 )
+
+// ErrInvalidExpireTime returns the error of the invalid expiry argument given to the command.
+func ErrInvalidExpireTime(cmdName string) error {
+	return fmt.Errorf("ERR invalid expire time in '%s' command", cmdName)
+}
+
+// ErrValueOutOfRange returns the error of the integer argument outside of the range.
+func ErrValueOutOfRange(min, max int) error {
+	return fmt.Errorf("ERR value is out of range, must be between %d and %d", min, max)
+}
+
+// ErrInputKeyNeeded returns the error of the command given no input key.
+func ErrInputKeyNeeded(cmdName string) error {
+	return fmt.Errorf("ERR at least 1 input key is needed for '%s' command", cmdName)
+}
@@ -3,10 +3,23 @@ package protocol
 import (
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"strconv"
 	"strings"
 	"time"
+
+	"gokv/app/internal/errors"
+)
+
+const (
+	// maxBulkLen is the maximum length of a bulk string, like "proto-max-bulk-len" of Redis
+	maxBulkLen = 512 * 1024 * 1024
+	// maxMultibulkLen is the maximum number of elements of an array
+	maxMultibulkLen = math.MaxInt32
+	// arrPrealloc bounds the elements allocated upfront for an array, as the client may not send
+	// as many as it announces
+	arrPrealloc = 1024
 )
 
 // EncType represents the different encoding types of the RESP value.
@@ -98,12 +111,28 @@ func ReadRespVal(conn net.Conn) (*RespVal, error) {
 
 	case '$':
 		c.Typ = BulkStrs
-		str, err := readUntilCRLF(conn)
+		strLen, err := strconv.Atoi(input[1:])
+		if err != nil {
+			return nil, err
+		}
+		if strLen < 0 {
+			c.Typ = Nulls
+			break
+		}
+		if strLen > maxBulkLen {
+			return nil, errors.ErrInvalidBulkLength
+		}
+
+		// Read by the length, as the bulk string may contain CRLF
+		str, err := readN(conn, strLen+2)
 		if err != nil {
 			return nil, err
 		}
+		if !strings.HasSuffix(str, "\r\n") {
+			return nil, fmt.Errorf("bulk string is not terminated by CRLF")
+		}
 
-		c.Val = str
+		c.Val = str[:strLen]
 
 	case '*':
 		c.Typ = Arrs
@@ -111,9 +140,12 @@ func ReadRespVal(conn net.Conn) (*RespVal, error) {
 		if err != nil {
 			return nil, err
 		}
+		if arrSize < 0 || arrSize > maxMultibulkLen {
+			return nil, errors.ErrInvalidMultibulkLength
+		}
 
 		// Read the array elements
-		arrElems := make([]*RespVal, 0, arrSize)
+		arrElems := make([]*RespVal, 0, min(arrSize, arrPrealloc))
 		for range arrSize {
 			elem, err := ReadRespVal(conn)
 			if err != nil {
@@ -183,6 +215,16 @@ func readUntilCRLF(conn net.Conn) (string, error) {
 	}
 }
 
+// readN reads exactly n bytes from the connection
+func readN(conn net.Conn, n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
 // Response encoding functions
 
 func ToSimpleStr(val string) string {
@@ -223,12 +265,13 @@ func ToArray(arr []string) string {
 		return "*-1\r\n"
 	}
 
-	str := fmt.Sprintf("*%d\r\n", len(arr))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%d\r\n", len(arr)))
 	for _, ele := range arr {
-		str += ele
+		sb.WriteString(ele)
 	}
 
-	return str
+	return sb.String()
 }
 
 func ToNullArray() string {
@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"gokv/app/internal/errors"
+)
+
+// readFrom returns the value read from the input sent by a client.
+func readFrom(t *testing.T, input string) (*RespVal, error) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		defer client.Close()
+		client.Write([]byte(input))
+	}()
+
+	return ReadRespVal(server)
+}
+
+func TestReadRespValLengths(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "bulk string", input: "$5\r\nhe\r\no\r\n", want: "he\r\no"},
+		{name: "empty bulk string", input: "$0\r\n\r\n", want: ""},
+		{name: "bulk length overflowing", input: "$9223372036854775807\r\n", wantErr: errors.ErrInvalidBulkLength},
+		{name: "bulk length past max", input: "$536870913\r\n", wantErr: errors.ErrInvalidBulkLength},
+		{name: "negative array length", input: "*-5\r\n", wantErr: errors.ErrInvalidMultibulkLength},
+		{name: "array length past max", input: "*9223372036854775807\r\n", wantErr: errors.ErrInvalidMultibulkLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := readFrom(t, tt.input)
+			if err != tt.wantErr {
+				t.Fatalf("ReadRespVal() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && val.BulkStrs() != tt.want {
+				t.Errorf("ReadRespVal() = %q, want %q", val.BulkStrs(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gokv/app/internal/storage"
+)
+
+// Config holds the configuration of the server.
+type Config struct {
+	// Databases is the number of logical databases
+	Databases int
+	// MaxMemory is the limit of the memory used by the keys in bytes. There is no limit if it's 0.
+	MaxMemory int64
+	// MaxMemoryPolicy is the policy of evicting the keys once the memory limit is reached
+	MaxMemoryPolicy storage.EvictionPolicy
+}
+
+// memoryUnits maps the units accepted in the memory sizes with their number of bytes
+var memoryUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1000,
+	"kb": 1024,
+	"m":  1000 * 1000,
+	"mb": 1024 * 1024,
+	"g":  1000 * 1000 * 1000,
+	"gb": 1024 * 1024 * 1024,
+}
+
+// ParseMemory parses the memory size such as "100mb" into the number of bytes.
+func ParseMemory(size string) (int64, error) {
+	size = strings.ToLower(size)
+	unitIdx := strings.IndexFunc(size, func(r rune) bool { return r < '0' || r > '9' })
+	if unitIdx == -1 {
+		unitIdx = len(size)
+	}
+
+	unit, ok := memoryUnits[size[unitIdx:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory unit in %q", size)
+	}
+
+	val, err := strconv.ParseInt(size[:unitIdx], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+
+	return val * unit, nil
+}
@@ -14,15 +14,24 @@ import (
 
 // Server represents the Redis server.
 type Server struct {
-	store    *storage.Mem
+	// dbs holds the logical databases, selected by their index
+	dbs      []*storage.Mem
 	registry *cmd.Registry
 }
 
 // NewServer creates a new Redis server instance.
-func NewServer() *Server {
+func NewServer(cfg Config) *Server {
+	dbs := make([]*storage.Mem, max(cfg.Databases, 1))
+	for i := range dbs {
+		dbs[i] = storage.NewMem()
+	}
+
 	return &Server{
-		store:    storage.NewMem(),
-		registry: cmd.NewRegistry(),
+		dbs: dbs,
+		registry: cmd.NewRegistry(&storage.Evictor{
+			MaxMemory: cfg.MaxMemory,
+			Policy:    cfg.MaxMemoryPolicy,
+		}),
 	}
 }
 
@@ -39,6 +48,8 @@ func (s *Server) HandleConnection(conn net.Conn) {
 	}
 
 	var (
+		// db is the index of the database selected by the connection
+		db                 int
 		isMultiCmdExecuted bool
 		// transactions holds the commands issued after the "MULTI" command
 		transactions [][]*protocol.RespVal
@@ -50,6 +61,10 @@ func (s *Server) HandleConnection(conn net.Conn) {
 		if err == io.EOF {
 			return
 		} else if err != nil {
+			// Let the client know why the connection is closed
+			if err == errors.ErrInvalidBulkLength || err == errors.ErrInvalidMultibulkLength {
+				returnResp(protocol.ToSimpErr(err.Error()))
+			}
 			fmt.Println("Failed to read the value: ", err.Error())
 			return
 		}
@@ -76,7 +91,7 @@ func (s *Server) HandleConnection(conn net.Conn) {
 			respStr = protocol.ToSimpleStr("OK")
 
 		case "EXEC":
-			respStr = s.handleExec(isMultiCmdExecuted, transactions)
+			respStr = s.handleExec(isMultiCmdExecuted, transactions, &db)
 			isMultiCmdExecuted = false
 			transactions = nil
 
@@ -86,14 +101,37 @@ func (s *Server) HandleConnection(conn net.Conn) {
 			transactions = nil
 
 		default:
-			respStr = s.registry.Execute(cmdName, cmd, s.store)
+			respStr = s.execute(cmdName, cmd, &db)
 		}
 
 		returnResp(respStr)
 	}
 }
 
-func (s *Server) handleExec(isMultiCmdExecuted bool, transactions [][]*protocol.RespVal) string {
+// execute executes the command against the database selected by the connection.
+func (s *Server) execute(cmdName string, cmd []*protocol.RespVal, db *int) string {
+	if cmdName == "SELECT" {
+		return s.handleSelect(cmd, db)
+	}
+
+	return s.registry.Execute(cmdName, cmd, s.dbs, *db)
+}
+
+func (s *Server) handleSelect(args []*protocol.RespVal, db *int) string {
+	if len(args) != 2 {
+		return protocol.ToSimpErr(errors.ErrInvalidCmd.Error())
+	}
+
+	idx, err := cmd.ParseDBIndex(args[1], len(s.dbs))
+	if err != nil {
+		return protocol.ToSimpErr(err.Error())
+	}
+
+	*db = idx
+	return protocol.ToSimpleStr("OK")
+}
+
+func (s *Server) handleExec(isMultiCmdExecuted bool, transactions [][]*protocol.RespVal, db *int) string {
 	if !isMultiCmdExecuted {
 		return protocol.ToSimpErr(errors.ErrExecWoMulti.Error())
 	}
@@ -102,7 +140,7 @@ func (s *Server) handleExec(isMultiCmdExecuted bool, transactions [][]*protocol.
 	resps := []string{}
 	for _, cmd := range transactions {
 		cmdName := strings.ToUpper(cmd[0].BulkStrs())
-		resps = append(resps, s.registry.Execute(cmdName, cmd, s.store))
+		resps = append(resps, s.execute(cmdName, cmd, db))
 	}
 
 	return protocol.ToArray(resps)
@@ -0,0 +1,208 @@
+package storage
+
+import "math"
+
+// BitfieldOpKind is the subcommand of "BITFIELD".
+type BitfieldOpKind int
+
+const (
+	BitfieldGet BitfieldOpKind = iota
+	BitfieldSet
+	BitfieldIncrBy
+)
+
+// BitfieldOverflow is the behavior of "BITFIELD" when a value doesn't fit in its field.
+type BitfieldOverflow int
+
+const (
+	// OverflowWrap wraps around the value, modulo the range of the field
+	OverflowWrap BitfieldOverflow = iota
+	// OverflowSat saturates the value to the minimum or maximum of the field
+	OverflowSat
+	// OverflowFail leaves the field unmodified, replying with nil
+	OverflowFail
+)
+
+// BitfieldOp is an operation on an integer field of a bitmap.
+type BitfieldOp struct {
+	Kind   BitfieldOpKind
+	Signed bool
+	// Bits is the width of the field, up to 64 for the signed and 63 for the unsigned fields
+	Bits   int
+	Offset int64
+	// Value is the value to set or the increment
+	Value    int64
+	Overflow BitfieldOverflow
+}
+
+// Bitfield performs the operations on the integer fields of the string value of the key, in order.
+// It returns the result of each of them, which is the value of the field for GET, its previous value
+// for SET, its new value for INCRBY, or nil if the value overflows with OverflowFail.
+func (m *Mem) Bitfield(key string, ops []BitfieldOp) ([]any, error) {
+	// Find the end of the last field written, the string being grown to hold it
+	var writeEnd int64
+	for _, op := range ops {
+		if op.Kind != BitfieldGet {
+			writeEnd = max(writeEnd, op.Offset+int64(op.Bits))
+		}
+	}
+
+	write := writeEnd > 0
+	if write {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	} else {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+
+	b, _, err := m.lookupBitmap(key, write)
+	if err != nil {
+		return nil, err
+	}
+
+	if byteLen := int((writeEnd + 7) >> 3); byteLen > len(b) {
+		b = append(b, make([]byte, byteLen-len(b))...)
+	}
+
+	results := make([]any, len(ops))
+	for i, op := range ops {
+		old := getBitfield(b, op.Offset, op.Bits, op.Signed)
+
+		var (
+			val  int64
+			incr int64
+		)
+		switch op.Kind {
+		case BitfieldGet:
+			results[i] = old
+			continue
+		case BitfieldSet:
+			val = op.Value
+		case BitfieldIncrBy:
+			val, incr = old, op.Value
+		}
+
+		next, overflow := bitfieldAdd(val, incr, op.Bits, op.Signed, op.Overflow)
+		if overflow && op.Overflow == OverflowFail {
+			results[i] = nil
+			continue
+		}
+
+		setBitfield(b, op.Offset, op.Bits, uint64(next))
+		if op.Kind == BitfieldSet {
+			results[i] = old
+		} else {
+			results[i] = next
+		}
+	}
+
+	if write {
+		m.mp.set(key, b)
+	}
+
+	return results, nil
+}
+
+// getBitfield returns the integer of the given width at the bit offset, most significant bit first.
+// The bits past the end of the bitmap are 0.
+func getBitfield(b []byte, offset int64, bits int, signed bool) int64 {
+	var u uint64
+	for i := range int64(bits) {
+		u = u<<1 | uint64(bitAt(b, offset+i))
+	}
+
+	// Extend the sign to the higher bits
+	if signed && bits < 64 && u&(1<<(bits-1)) != 0 {
+		u |= math.MaxUint64 << bits
+	}
+
+	return int64(u)
+}
+
+// setBitfield sets the field of the given width at the bit offset to the lower bits of the value, most
+// significant bit first. The bitmap must hold the field.
+func setBitfield(b []byte, offset int64, bits int, u uint64) {
+	for i := range int64(bits) {
+		pos := offset + i
+		mask := byte(1) << (7 - pos&7)
+		if u&(1<<(int64(bits)-1-i)) != 0 {
+			b[pos>>3] |= mask
+		} else {
+			b[pos>>3] &^= mask
+		}
+	}
+}
+
+// bitfieldAdd returns the sum of the value and the increment, limited to the range of the field of
+// the given width according to the overflow behavior. It also returns whether the sum overflows.
+func bitfieldAdd(val, incr int64, bits int, signed bool, overflow BitfieldOverflow) (int64, bool) {
+	if signed {
+		return signedBitfieldAdd(val, incr, bits, overflow)
+	}
+	return unsignedBitfieldAdd(uint64(val), incr, bits, overflow)
+}
+
+func signedBitfieldAdd(val, incr int64, bits int, overflow BitfieldOverflow) (int64, bool) {
+	maxVal := int64(math.MaxInt64)
+	if bits < 64 {
+		maxVal = 1<<(bits-1) - 1
+	}
+	minVal := -maxVal - 1
+
+	// The limits of the increment may overflow, but they're used only once the value is known to be
+	// in the range of the field, and only in the direction in which they don't overflow for 64 bits
+	maxIncr, minIncr := maxVal-val, minVal-val
+
+	var limit int64
+	switch {
+	case val > maxVal:
+		limit = maxVal
+	case val < minVal:
+		limit = minVal
+	case incr > 0 && (bits != 64 || val >= 0) && incr > maxIncr:
+		limit = maxVal
+	case incr < 0 && (bits != 64 || val < 0) && incr < minIncr:
+		limit = minVal
+	default:
+		return val + incr, false
+	}
+
+	if overflow == OverflowSat {
+		return limit, true
+	}
+
+	// Wrap around, propagating the sign bit to the higher bits
+	sum := uint64(val) + uint64(incr)
+	if bits < 64 {
+		mask := uint64(math.MaxUint64) << bits
+		if sum&(1<<(bits-1)) != 0 {
+			sum |= mask
+		} else {
+			sum &^= mask
+		}
+	}
+
+	return int64(sum), true
+}
+
+func unsignedBitfieldAdd(val uint64, incr int64, bits int, overflow BitfieldOverflow) (int64, bool) {
+	maxVal := uint64(1)<<bits - 1
+	maxIncr, minIncr := int64(maxVal-val), -int64(val)
+
+	var limit uint64
+	switch {
+	case val > maxVal || (incr > 0 && incr > maxIncr):
+		limit = maxVal
+	case incr < 0 && incr < minIncr:
+		limit = 0
+	default:
+		return int64(val) + incr, false
+	}
+
+	if overflow == OverflowSat {
+		return int64(limit), true
+	}
+
+	return int64((val + uint64(incr)) & maxVal), true
+}
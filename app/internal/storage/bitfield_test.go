@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// bitfieldCase is an operation on a field holding init, whose value after the operation is wrap or
+// sat depending on the overflow behavior.
+type bitfieldCase struct {
+	name      string
+	kind      BitfieldOpKind
+	init      int64
+	value     int64
+	wrap      int64
+	sat       int64
+	overflows bool
+}
+
+// bitfieldCases returns the operations at the limits of the field of the given width.
+func bitfieldCases(signed bool, bits int) []bitfieldCase {
+	var minVal, maxVal int64
+	switch {
+	case !signed:
+		maxVal = 1<<bits - 1
+	case bits == 64:
+		minVal, maxVal = math.MinInt64, math.MaxInt64
+	default:
+		maxVal = 1<<(bits-1) - 1
+		minVal = -maxVal - 1
+	}
+
+	cases := []bitfieldCase{
+		{name: "incr max by 1", kind: BitfieldIncrBy, init: maxVal, value: 1, wrap: minVal, sat: maxVal, overflows: true},
+		{name: "incr min by -1", kind: BitfieldIncrBy, init: minVal, value: -1, wrap: maxVal, sat: minVal, overflows: true},
+		{name: "incr max by -1", kind: BitfieldIncrBy, init: maxVal, value: -1, wrap: maxVal - 1, sat: maxVal - 1},
+		{name: "incr min by 1", kind: BitfieldIncrBy, init: minVal, value: 1, wrap: minVal + 1, sat: minVal + 1},
+		{name: "set max", kind: BitfieldSet, init: minVal, value: maxVal, wrap: maxVal, sat: maxVal},
+		{name: "set min", kind: BitfieldSet, init: maxVal, value: minVal, wrap: minVal, sat: minVal},
+	}
+
+	if signed {
+		cases = append(cases,
+			// Twice the max is -2 modulo the range, and twice the min is 0
+			bitfieldCase{name: "incr max by max", kind: BitfieldIncrBy, init: maxVal, value: maxVal, wrap: -2, sat: maxVal, overflows: true},
+			bitfieldCase{name: "incr min by min", kind: BitfieldIncrBy, init: minVal, value: minVal, wrap: 0, sat: minVal, overflows: true},
+		)
+	} else {
+		cases = append(cases,
+			bitfieldCase{name: "incr max by max", kind: BitfieldIncrBy, init: maxVal, value: maxVal, wrap: maxVal - 1, sat: maxVal, overflows: true},
+			bitfieldCase{name: "incr max by min int64", kind: BitfieldIncrBy, init: maxVal, value: math.MinInt64, wrap: maxVal, sat: 0, overflows: true},
+			// The negative values are out of range of the unsigned fields, saturating to the max
+			bitfieldCase{name: "set -1", kind: BitfieldSet, value: -1, wrap: maxVal, sat: maxVal, overflows: true},
+		)
+	}
+
+	if maxVal < math.MaxInt64 {
+		// The max int64 sets all the bits of the field, and the min int64 clears them. The min int64
+		// is out of range of the unsigned fields too.
+		allBits, minSat := maxVal, maxVal
+		if signed {
+			allBits, minSat = -1, minVal
+		}
+		cases = append(cases,
+			bitfieldCase{name: "set max+1", kind: BitfieldSet, value: maxVal + 1, wrap: minVal, sat: maxVal, overflows: true},
+			bitfieldCase{name: "set max int64", kind: BitfieldSet, value: math.MaxInt64, wrap: allBits, sat: maxVal, overflows: true},
+			bitfieldCase{name: "set min int64", kind: BitfieldSet, init: maxVal, value: math.MinInt64, wrap: 0, sat: minSat, overflows: true},
+		)
+	}
+	if signed && minVal > math.MinInt64 {
+		cases = append(cases,
+			bitfieldCase{name: "set min-1", kind: BitfieldSet, init: maxVal, value: minVal - 1, wrap: maxVal, sat: minVal, overflows: true},
+		)
+	}
+
+	return cases
+}
+
+func TestBitfieldOverflow(t *testing.T) {
+	widths := []struct {
+		signed bool
+		bits   int
+	}{
+		{signed: true, bits: 2},
+		{signed: true, bits: 8},
+		{signed: true, bits: 63},
+		{signed: true, bits: 64},
+		{signed: false, bits: 1},
+		{signed: false, bits: 8},
+		{signed: false, bits: 63},
+	}
+	overflows := []struct {
+		name     string
+		overflow BitfieldOverflow
+	}{
+		{name: "WRAP", overflow: OverflowWrap},
+		{name: "SAT", overflow: OverflowSat},
+		{name: "FAIL", overflow: OverflowFail},
+	}
+
+	// The field straddles bytes
+	const offset = 3
+
+	for _, w := range widths {
+		typ := fmt.Sprintf("u%d", w.bits)
+		if w.signed {
+			typ = fmt.Sprintf("i%d", w.bits)
+		}
+
+		for _, tt := range bitfieldCases(w.signed, w.bits) {
+			for _, o := range overflows {
+				t.Run(fmt.Sprintf("%s %s %s", typ, tt.name, o.name), func(t *testing.T) {
+					m := NewMem()
+					field := BitfieldOp{Signed: w.signed, Bits: w.bits, Offset: offset}
+
+					initOp := field
+					initOp.Kind, initOp.Value = BitfieldSet, tt.init
+					if _, err := m.Bitfield("k", []BitfieldOp{initOp}); err != nil {
+						t.Fatalf("Bitfield() initial error = %v", err)
+					}
+
+					op := field
+					op.Kind, op.Value, op.Overflow = tt.kind, tt.value, o.overflow
+					getOp := field
+					getOp.Kind = BitfieldGet
+
+					results, err := m.Bitfield("k", []BitfieldOp{op, getOp})
+					if err != nil {
+						t.Fatalf("Bitfield() error = %v", err)
+					}
+
+					want := tt.wrap
+					if o.overflow == OverflowSat {
+						want = tt.sat
+					}
+
+					var wantResult any = want
+					switch {
+					case tt.overflows && o.overflow == OverflowFail:
+						wantResult, want = nil, tt.init
+					case tt.kind == BitfieldSet:
+						wantResult = tt.init
+					}
+
+					if results[0] != wantResult {
+						t.Errorf("result = %v, want %v", results[0], wantResult)
+					}
+					if results[1] != want {
+						t.Errorf("field after = %v, want %v", results[1], want)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package storage
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"gokv/app/internal/errors"
+)
+
+// MaxBitOffset is the largest bit offset of a bitmap, as the bitmap is a string of limited length
+const MaxBitOffset = maxStringSize*8 - 1
+
+// BitOp is the bitwise operation of "BITOP".
+type BitOp int
+
+const (
+	BitOpAnd BitOp = iota
+	BitOpOr
+	BitOpXor
+	BitOpNot
+	// BitOpDiff sets the bits set in the first source but in none of the others
+	BitOpDiff
+)
+
+// BitRange is a range of a bitmap, its offsets being either in bytes or in bits. The negative
+// offsets are counted from the end of the bitmap.
+type BitRange struct {
+	Start int64
+	End   int64
+	// HasEnd is false if the range extends to the end of the bitmap, ignoring End
+	HasEnd bool
+	// IsBit is true if the offsets are in bits rather than in bytes
+	IsBit bool
+}
+
+// bitIndices returns the first and the last bit of the range, both inclusive, for the bitmap of the
+// given number of bytes. It returns false if the range is empty.
+func (r BitRange) bitIndices(byteLen int) (int64, int64, bool) {
+	total := int64(byteLen)
+	if r.IsBit {
+		total *= 8
+	}
+
+	start, end := r.Start, r.End
+	if !r.HasEnd {
+		end = -1
+	}
+
+	// Handle negative offsets
+	if start < 0 {
+		start = max(total+start, 0)
+	}
+	if end < 0 {
+		end = max(total+end, 0)
+	}
+	end = min(end, total-1)
+
+	if start > end {
+		return 0, 0, false
+	}
+	if r.IsBit {
+		return start, end, true
+	}
+	return start * 8, end*8 + 7, true
+}
+
+// lookupBitmap returns the string value of the key as bytes. Caller must hold the lock.
+func (m *Mem) lookupBitmap(key string, write bool) ([]byte, bool, error) {
+	var (
+		val any
+		ok  bool
+	)
+	if write {
+		val, ok = m.lookupKeyWrite(key)
+	} else {
+		val, ok = m.lookupKey(key)
+	}
+
+	if !ok {
+		return nil, false, nil
+	}
+	if !isString(val) {
+		return nil, false, errors.ErrWrongType
+	}
+
+	return bytesOf(val), true, nil
+}
+
+// SetBit sets the bit at the offset of the string value of the key, growing the string if needed.
+// It returns the previous value of the bit.
+func (m *Mem) SetBit(key string, offset int64, bit byte) (byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, _, err := m.lookupBitmap(key, true)
+	if err != nil {
+		return 0, err
+	}
+
+	byteIdx := int(offset >> 3)
+	if byteIdx >= len(b) {
+		b = append(b, make([]byte, byteIdx+1-len(b))...)
+	}
+
+	mask := byte(1) << (7 - offset&7)
+	prev := b[byteIdx] & mask
+	if bit == 1 {
+		b[byteIdx] |= mask
+	} else {
+		b[byteIdx] &^= mask
+	}
+	m.mp.set(key, b)
+
+	if prev != 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// GetBit returns the bit at the offset of the string value of the key. The bits past the end of
+// the string are 0.
+func (m *Mem) GetBit(key string, offset int64) (byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, _, err := m.lookupBitmap(key, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return bitAt(b, offset), nil
+}
+
+// bitAt returns the bit at the offset, 0 if it's past the end of the bitmap.
+func bitAt(b []byte, offset int64) byte {
+	byteIdx := offset >> 3
+	if byteIdx >= int64(len(b)) {
+		return 0
+	}
+
+	return (b[byteIdx] >> (7 - offset&7)) & 1
+}
+
+// BitCount returns the number of bits set in the range of the string value of the key.
+func (m *Mem) BitCount(key string, r BitRange) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, _, err := m.lookupBitmap(key, false)
+	if err != nil {
+		return 0, err
+	}
+
+	start, end, ok := r.bitIndices(len(b))
+	if !ok {
+		return 0, nil
+	}
+
+	var cnt int64
+	// Count the leading bits up to the byte boundary
+	for ; start <= end && start&7 != 0; start++ {
+		cnt += int64(bitAt(b, start))
+	}
+
+	// Count the whole bytes, a word at a time
+	byteIdx, lastByte := start>>3, (end+1)>>3
+	for ; byteIdx+8 <= lastByte; byteIdx += 8 {
+		cnt += int64(bits.OnesCount64(binary.LittleEndian.Uint64(b[byteIdx:])))
+	}
+	for ; byteIdx < lastByte; byteIdx++ {
+		cnt += int64(bits.OnesCount8(b[byteIdx]))
+	}
+
+	// Count the trailing bits after the byte boundary
+	for start = max(start, byteIdx<<3); start <= end; start++ {
+		cnt += int64(bitAt(b, start))
+	}
+
+	return cnt, nil
+}
+
+// BitPos returns the offset of the first bit of the given value in the range of the string value
+// of the key, or -1 if there is none. The missing key is treated as the empty string. When looking
+// for a clear bit without the end of the range, the string is considered padded with zeros on the right.
+func (m *Mem) BitPos(key string, bit byte, r BitRange) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok, err := m.lookupBitmap(key, false)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if bit == 1 {
+			return -1, nil
+		}
+		return 0, nil
+	}
+
+	start, end, ok := r.bitIndices(len(b))
+	if !ok {
+		return -1, nil
+	}
+
+	if pos := bitPos(b, bit, start, end); pos != -1 {
+		return pos, nil
+	}
+	if bit == 0 && !r.HasEnd {
+		return end + 1, nil
+	}
+
+	return -1, nil
+}
+
+// bitPos returns the offset of the first bit of the given value between the start and end offsets,
+// both inclusive, or -1 if there is none.
+func bitPos(b []byte, bit byte, start, end int64) int64 {
+	// Check the leading bits up to the byte boundary
+	for ; start <= end && start&7 != 0; start++ {
+		if bitAt(b, start) == bit {
+			return start
+		}
+	}
+
+	// Skip the whole bytes having none of the bits, a word at a time
+	var skipByte byte
+	if bit == 0 {
+		skipByte = 0xff
+	}
+	skipWord := uint64(skipByte) * 0x0101010101010101
+
+	byteIdx, lastByte := start>>3, (end+1)>>3
+	for byteIdx+8 <= lastByte && binary.LittleEndian.Uint64(b[byteIdx:]) == skipWord {
+		byteIdx += 8
+	}
+	for byteIdx < lastByte && b[byteIdx] == skipByte {
+		byteIdx++
+	}
+
+	if byteIdx < lastByte {
+		found := b[byteIdx]
+		if bit == 0 {
+			found = ^found
+		}
+		return byteIdx<<3 + int64(bits.LeadingZeros8(found))
+	}
+
+	// Check the trailing bits after the byte boundary
+	for start = max(start, byteIdx<<3); start <= end; start++ {
+		if bitAt(b, start) == bit {
+			return start
+		}
+	}
+
+	return -1
+}
+
+// BitOp performs the bitwise operation between the string values of the source keys, and stores the
+// result in the destination key. The shorter strings are treated as padded with zeros, and the missing
+// keys as the empty strings. It returns the length of the result, the destination key being removed
+// if it's empty.
+func (m *Mem) BitOp(op BitOp, dst string, srcs ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operands := make([][]byte, len(srcs))
+	var maxLen int
+	for i, src := range srcs {
+		b, _, err := m.lookupBitmap(src, true)
+		if err != nil {
+			return 0, err
+		}
+
+		operands[i] = b
+		maxLen = max(maxLen, len(b))
+	}
+
+	if maxLen == 0 {
+		m.deleteKey(dst)
+		return 0, nil
+	}
+
+	result := make([]byte, maxLen)
+	copy(result, operands[0])
+
+	switch op {
+	case BitOpNot:
+		applyWords(result, result, func(a, _ uint64) uint64 { return ^a })
+
+	case BitOpAnd:
+		for _, b := range operands[1:] {
+			applyWords(result, b, func(a, b uint64) uint64 { return a & b })
+			// The bytes past the end of the operand are ANDed with zeros
+			clear(result[len(b):])
+		}
+
+	case BitOpOr:
+		for _, b := range operands[1:] {
+			applyWords(result, b, func(a, b uint64) uint64 { return a | b })
+		}
+
+	case BitOpXor:
+		for _, b := range operands[1:] {
+			applyWords(result, b, func(a, b uint64) uint64 { return a ^ b })
+		}
+
+	case BitOpDiff:
+		for _, b := range operands[1:] {
+			applyWords(result, b, func(a, b uint64) uint64 { return a &^ b })
+		}
+	}
+
+	m.deleteKey(dst)
+	m.mp.set(dst, result)
+
+	return len(result), nil
+}
+
+// applyWords sets the bytes of dst to the result of the operation between them and the bytes of src,
+// a word at a time. Only the bytes up to the shorter of both are modified.
+func applyWords(dst, src []byte, op func(a, b uint64) uint64) {
+	n := min(len(dst), len(src))
+
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], op(binary.LittleEndian.Uint64(dst[i:]), binary.LittleEndian.Uint64(src[i:])))
+	}
+	for ; i < n; i++ {
+		dst[i] = byte(op(uint64(dst[i]), uint64(src[i])))
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// listWaiter is a connection blocked on lists, until one of them gets an element.
+type listWaiter struct {
+	keys []string
+	// serve pops from the list of the key for the connection. It returns nil if the connection
+	// isn't served, and it's called with the write lock held.
+	serve func(key string) (any, error)
+	// result receives the outcome of serve once the connection is served
+	result chan listWaitResult
+	served bool
+}
+
+type listWaitResult struct {
+	val any
+	err error
+}
+
+// PoppedList is the elements popped from the list of the key by a blocking pop.
+type PoppedList struct {
+	Key  string
+	Vals []any
+}
+
+// Bpop pops up to count elements from the given side of the first non-empty list of the keys. If all
+// of them are empty, it waits for an element to be pushed to any of them, the connections blocked on the
+// same key being served in the order they blocked. The timeout of 0 waits forever. It returns nil
+// if the timeout passes.
+func (m *Mem) Bpop(keys []string, side ListSide, count int, timeout time.Duration) (*PoppedList, error) {
+	val, err := m.blockOn(keys, timeout, func(key string) (any, error) {
+		removed, err := m.pop(key, side, count)
+		if err != nil || removed == nil {
+			return nil, err
+		}
+
+		return &PoppedList{Key: key, Vals: removed}, nil
+	})
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	return val.(*PoppedList), nil
+}
+
+// blockOn calls serve on the keys in order until it serves the connection. Otherwise, it blocks the
+// connection on all of them until one of them gets an element, or the timeout passes. The timeout
+// of 0 waits forever. It returns nil if the timeout passes.
+func (m *Mem) blockOn(keys []string, timeout time.Duration, serve func(key string) (any, error)) (any, error) {
+	m.mu.Lock()
+	for _, key := range keys {
+		val, err := serve(key)
+		if err != nil || val != nil {
+			m.mu.Unlock()
+			return val, err
+		}
+	}
+
+	// Block while holding the lock, so that no element can be pushed before the connection waits
+	w := &listWaiter{
+		keys:   slices.Compact(slices.Sorted(slices.Values(keys))),
+		serve:  serve,
+		result: make(chan listWaitResult, 1),
+	}
+	for _, key := range w.keys {
+		m.lbp.waitQ[key] = append(m.lbp.waitQ[key], w)
+	}
+	m.mu.Unlock()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case res := <-w.result:
+		return res.val, res.err
+
+	case <-timeoutC:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		// The connection may have been served right as the timeout passed
+		if w.served {
+			res := <-w.result
+			return res.val, res.err
+		}
+
+		m.unblock(w)
+		return nil, nil
+	}
+}
+
+// unblock removes the connection from the queues of all its keys. Caller must hold the write lock.
+func (m *Mem) unblock(w *listWaiter) {
+	for _, key := range w.keys {
+		waitList := slices.DeleteFunc(m.lbp.waitQ[key], func(o *listWaiter) bool { return o == w })
+		if len(waitList) == 0 {
+			delete(m.lbp.waitQ, key)
+		} else {
+			m.lbp.waitQ[key] = waitList
+		}
+	}
+}
+
+// signalListReady serves the connections blocked on the key, as an element was inserted in its list.
+// Serving a connection may insert elements in other lists, whose connections are then served in turn.
+// Caller must hold the write lock.
+func (m *Mem) signalListReady(key string) {
+	if len(m.lbp.waitQ[key]) == 0 {
+		return
+	}
+
+	m.lbp.ready = append(m.lbp.ready, key)
+	// The keys are already being served further up the stack
+	if len(m.lbp.ready) > 1 {
+		return
+	}
+
+	for len(m.lbp.ready) > 0 {
+		m.serveWaiters(m.lbp.ready[0])
+		m.lbp.ready = m.lbp.ready[1:]
+	}
+}
+
+// serveWaiters serves the connections blocked on the key in order, as long as its list has elements.
+// Caller must hold the write lock.
+func (m *Mem) serveWaiters(key string) {
+	for len(m.lbp.waitQ[key]) > 0 {
+		if _, ok, err := lookupValue[*quicklist](m, key, true); err != nil || !ok {
+			return
+		}
+
+		w := m.lbp.waitQ[key][0]
+		val, err := w.serve(key)
+		if err == nil && val == nil {
+			return
+		}
+
+		m.unblock(w)
+		w.served = true
+		w.result <- listWaitResult{val: val, err: err}
+	}
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+type bpopResult struct {
+	popped *PoppedList
+	err    error
+}
+
+// startBpop blocks a connection on the keys in the background, and waits until it's queued on all
+// of them, so that the connections are served in the order they're started.
+func startBpop(t *testing.T, m *Mem, keys []string, timeout time.Duration) <-chan bpopResult {
+	t.Helper()
+
+	queued := make(map[string]int, len(keys))
+	m.mu.RLock()
+	for _, key := range keys {
+		queued[key] = len(m.lbp.waitQ[key])
+	}
+	m.mu.RUnlock()
+
+	done := make(chan bpopResult, 1)
+	go func() {
+		popped, err := m.Bpop(keys, ListLeft, 1, timeout)
+		done <- bpopResult{popped: popped, err: err}
+	}()
+
+	waitFor(t, func() bool {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		for _, key := range keys {
+			if len(m.lbp.waitQ[key]) <= queued[key] {
+				return false
+			}
+		}
+		return true
+	})
+
+	return done
+}
+
+// waitFor polls the condition until it holds, failing the test if it takes too long.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// receive returns the outcome of the blocked connection, failing the test if it isn't served.
+func receive(t *testing.T, done <-chan bpopResult) bpopResult {
+	t.Helper()
+
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not served in time")
+		return bpopResult{}
+	}
+}
+
+// assertPopped checks that the connection popped the value from the list of the key.
+func assertPopped(t *testing.T, res bpopResult, key, val string) {
+	t.Helper()
+
+	if res.err != nil {
+		t.Fatalf("Bpop() error = %v", res.err)
+	}
+	if res.popped == nil || res.popped.Key != key || !slices.Equal(res.popped.Vals, []any{val}) {
+		t.Errorf("Bpop() = %+v, want %s from %s", res.popped, val, key)
+	}
+}
+
+// assertNotServed checks that the connection is still blocked.
+func assertNotServed(t *testing.T, done <-chan bpopResult) {
+	t.Helper()
+
+	select {
+	case res := <-done:
+		t.Fatalf("Bpop() = %+v, %v, want still blocked", res.popped, res.err)
+	default:
+	}
+}
+
+// assertNoWaiters checks that no connection is blocked on any key.
+func assertNoWaiters(t *testing.T, m *Mem) {
+	t.Helper()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.lbp.waitQ) != 0 {
+		t.Errorf("waitQ = %v, want empty", m.lbp.waitQ)
+	}
+}
+
+func TestBpopServesInBlockingOrder(t *testing.T) {
+	m := NewMem()
+	first := startBpop(t, m, []string{"k"}, 0)
+	second := startBpop(t, m, []string{"k"}, 0)
+
+	if _, err := m.Rpush("k", "a"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, first), "k", "a")
+	assertNotServed(t, second)
+
+	if _, err := m.Rpush("k", "b"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, second), "k", "b")
+	assertNoWaiters(t, m)
+}
+
+func TestBpopServesAsManyAsPushed(t *testing.T) {
+	m := NewMem()
+	first := startBpop(t, m, []string{"k"}, 0)
+	second := startBpop(t, m, []string{"k"}, 0)
+	third := startBpop(t, m, []string{"k"}, 0)
+
+	if _, err := m.Rpush("k", "a", "b"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, first), "k", "a")
+	assertPopped(t, receive(t, second), "k", "b")
+	assertNotServed(t, third)
+
+	if n, err := m.Llen("k"); err != nil || n != 0 {
+		t.Errorf("Llen() = %d, %v, want 0", n, err)
+	}
+
+	if _, err := m.Lpush("k", "c"); err != nil {
+		t.Fatalf("Lpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, third), "k", "c")
+	assertNoWaiters(t, m)
+}
+
+func TestBpopOnSeveralKeys(t *testing.T) {
+	m := NewMem()
+	multi := startBpop(t, m, []string{"a", "b", "a"}, 0)
+	onB := startBpop(t, m, []string{"b"}, 0)
+
+	if _, err := m.Rpush("b", "x"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, multi), "b", "x")
+	assertNotServed(t, onB)
+
+	// The served connection is no longer blocked on its other key
+	m.mu.RLock()
+	waitingOnA := len(m.lbp.waitQ["a"])
+	m.mu.RUnlock()
+	if waitingOnA != 0 {
+		t.Errorf("connections blocked on a = %d, want 0", waitingOnA)
+	}
+
+	if _, err := m.Rpush("a", "y"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	if n, err := m.Llen("a"); err != nil || n != 1 {
+		t.Errorf("Llen() = %d, %v, want 1", n, err)
+	}
+
+	if _, err := m.Rpush("b", "z"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	assertPopped(t, receive(t, onB), "b", "z")
+	assertNoWaiters(t, m)
+}
+
+func TestBpopFirstNonEmptyKey(t *testing.T) {
+	m := NewMem()
+	if _, err := m.Rpush("b", "x"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	if _, err := m.Rpush("c", "y"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+
+	popped, err := m.Bpop([]string{"a", "b", "c"}, ListLeft, 1, time.Millisecond)
+	assertPopped(t, bpopResult{popped: popped, err: err}, "b", "x")
+	assertNoWaiters(t, m)
+}
+
+func TestBpopTimeout(t *testing.T) {
+	m := NewMem()
+
+	popped, err := m.Bpop([]string{"a", "b"}, ListLeft, 1, 10*time.Millisecond)
+	if err != nil || popped != nil {
+		t.Errorf("Bpop() = %+v, %v, want nil", popped, err)
+	}
+	assertNoWaiters(t, m)
+
+	// The timed out connection doesn't pop the elements pushed afterwards
+	if _, err := m.Rpush("a", "x"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+	if n, err := m.Llen("a"); err != nil || n != 1 {
+		t.Errorf("Llen() = %d, %v, want 1", n, err)
+	}
+}
+
+func TestBpopTimeoutDuringPush(t *testing.T) {
+	m := NewMem()
+	done := startBpop(t, m, []string{"k"}, 10*time.Millisecond)
+
+	// The timeout passes while the push holds the lock, so the connection is served by the push
+	// before it can unblock itself
+	m.mu.Lock()
+	time.Sleep(50 * time.Millisecond)
+	ql := newQuicklist()
+	ql.pushTail("a")
+	m.mp.set("k", ql)
+	m.signalListReady("k")
+	m.mu.Unlock()
+
+	assertPopped(t, receive(t, done), "k", "a")
+	assertNoWaiters(t, m)
+	if n, err := m.Llen("k"); err != nil || n != 0 {
+		t.Errorf("Llen() = %d, %v, want 0", n, err)
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"time"
+)
+
+// lockPair write-locks both the stores in a consistent order, so that the concurrent operations
+// on the same pair of stores don't deadlock. It returns the function to unlock them.
+func lockPair(a, b *Mem) func() {
+	if a == b {
+		a.mu.Lock()
+		return a.mu.Unlock
+	}
+
+	if a.id > b.id {
+		a, b = b, a
+	}
+
+	a.mu.Lock()
+	b.mu.Lock()
+
+	return func() {
+		b.mu.Unlock()
+		a.mu.Unlock()
+	}
+}
+
+// Move moves the key along with its time-to-live from src to dst store, unless the key already
+// exists in dst. It returns whether the key was moved.
+func Move(src, dst *Mem, key string) bool {
+	unlock := lockPair(src, dst)
+	defer unlock()
+
+	val, ok := src.lookupKeyWrite(key)
+	if !ok {
+		return false
+	}
+	if _, ok := dst.lookupKeyWrite(key); ok {
+		return false
+	}
+
+	at, hasTTL := src.expires[key]
+	src.deleteKey(key)
+
+	dst.mp.set(key, val)
+	if hasTTL {
+		dst.expires[key] = at
+	}
+
+	dst.trackFieldExpires(key, val)
+	dst.signalKeyAsReady(key, val)
+
+	return true
+}
+
+// Copy copies the value of src key of the from store along with its time-to-live to dst key of the
+// to store. If replace is false, it doesn't copy if dst key already exists. It returns whether the
+// value was copied.
+func Copy(from, to *Mem, src, dst string, replace bool) bool {
+	unlock := lockPair(from, to)
+	defer unlock()
+
+	val, ok := from.lookupKeyWrite(src)
+	if !ok {
+		return false
+	}
+
+	if _, ok := to.lookupKeyWrite(dst); ok {
+		if !replace {
+			return false
+		}
+
+		to.deleteKey(dst)
+	}
+
+	val = copyValue(val)
+	to.mp.set(dst, val)
+	if at, ok := from.expires[src]; ok {
+		to.expires[dst] = at
+	}
+
+	to.trackFieldExpires(dst, val)
+	to.signalKeyAsReady(dst, val)
+
+	return true
+}
+
+// Swap swaps all the keys of both the stores. The connections blocked on either store are served
+// with the swapped keys.
+func Swap(a, b *Mem) {
+	unlock := lockPair(a, b)
+	defer unlock()
+
+	a.mp, b.mp = b.mp, a.mp
+	a.expires, b.expires = b.expires, a.expires
+	a.fieldExpires, b.fieldExpires = b.fieldExpires, a.fieldExpires
+
+	a.signalWaiters()
+	b.signalWaiters()
+}
+
+// signalWaiters serves the connections blocked on the keys which may have changed underneath them.
+// Caller must hold the write lock.
+func (m *Mem) signalWaiters() {
+	keys := make([]string, 0, len(m.lbp.waitQ))
+	for key := range m.lbp.waitQ {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		m.signalListReady(key)
+	}
+}
+
+// Flush removes all the keys of the store. If async is true, the removed keys are released in background.
+func (m *Mem) Flush(async bool) {
+	m.mu.Lock()
+	old := m.mp
+	m.mp = newDict()
+	m.expires = make(map[string]time.Time)
+	m.fieldExpires = make(map[string]struct{})
+	m.mu.Unlock()
+
+	if async {
+		go freeDict(old)
+	}
+}
+
+// KeyspaceInfo returns the number of keys, the number of keys having a time-to-live and
+// their average time-to-live.
+func (m *Mem) KeyspaceInfo() (int, int, time.Duration) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.expires) == 0 {
+		return m.mp.len(), 0, 0
+	}
+
+	var (
+		now      = time.Now()
+		totalTTL time.Duration
+	)
+	for _, at := range m.expires {
+		totalTTL += max(at.Sub(now), 0)
+	}
+
+	return m.mp.len(), len(m.expires), totalTTL / time.Duration(len(m.expires))
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// dictMinSize is the least number of buckets of the dict
+	dictMinSize = 4
+	// dictShrinkRatio is the ratio of buckets to elements beyond which the dict gets shrunk
+	dictShrinkRatio = 8
+)
+
+// dictEntry is a single key-value pair of the dict.
+type dictEntry struct {
+	key  string
+	val  any
+	next *dictEntry
+	// size is the approximate memory used by the entry in bytes
+	size int64
+	// atime is the last access time in unix milliseconds, used by the LRU eviction
+	atime atomic.Int64
+	// lfu holds the logarithmic access frequency counter in its lowest 8 bits, and the unix minutes
+	// at which the counter was last decremented in the rest, used by the LFU eviction
+	lfu atomic.Uint64
+}
+
+// dict is a hash table with the power of two number of buckets, each chaining its entries.
+// Unlike the Go map, it can be iterated with a stateless cursor which returns every key present
+// for the whole iteration, even if the table grows or shrinks in between.
+type dict struct {
+	buckets []*dictEntry
+	size    int
+	seed    maphash.Seed
+	// used is the approximate memory used by all the entries in bytes
+	used int64
+}
+
+// newDict creates an empty dict.
+func newDict() *dict {
+	return &dict{
+		buckets: make([]*dictEntry, dictMinSize),
+		seed:    maphash.MakeSeed(),
+	}
+}
+
+// bucketIdx returns the index of the bucket holding the key.
+func (d *dict) bucketIdx(key string) uint64 {
+	return maphash.String(d.seed, key) & uint64(len(d.buckets)-1)
+}
+
+// find returns the entry of the key, if present.
+func (d *dict) find(key string) *dictEntry {
+	for e := d.buckets[d.bucketIdx(key)]; e != nil; e = e.next {
+		if e.key == key {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// get returns the value of the key, if present.
+func (d *dict) get(key string) (any, bool) {
+	e := d.find(key)
+	if e == nil {
+		return nil, false
+	}
+
+	return e.val, true
+}
+
+// set adds the key or replaces the value of the existing one. It must also be called after the value
+// is modified in place, to keep the memory usage up to date.
+func (d *dict) set(key string, val any) {
+	if e := d.find(key); e != nil {
+		e.val = val
+		d.updateSize(e)
+		return
+	}
+
+	idx := d.bucketIdx(key)
+	e := &dictEntry{key: key, val: val, next: d.buckets[idx]}
+	e.atime.Store(time.Now().UnixMilli())
+	e.lfu.Store(lfuPack(lfuInitVal, time.Now()))
+
+	d.buckets[idx] = e
+	d.size++
+	d.updateSize(e)
+
+	if d.size > len(d.buckets) {
+		d.resize(len(d.buckets) * 2)
+	}
+}
+
+// delete removes the key and returns its value, if present.
+func (d *dict) delete(key string) (any, bool) {
+	idx := d.bucketIdx(key)
+	for prev, e := (*dictEntry)(nil), d.buckets[idx]; e != nil; prev, e = e, e.next {
+		if e.key != key {
+			continue
+		}
+
+		if prev == nil {
+			d.buckets[idx] = e.next
+		} else {
+			prev.next = e.next
+		}
+		d.size--
+		d.used -= e.size
+
+		if len(d.buckets) > dictMinSize && d.size*dictShrinkRatio < len(d.buckets) {
+			d.resize(len(d.buckets) / 2)
+		}
+
+		return e.val, true
+	}
+
+	return nil, false
+}
+
+// updateSize re-estimates the memory used by the entry.
+func (d *dict) updateSize(e *dictEntry) {
+	size := entryOverhead + int64(len(e.key)) + estimateSize(e.val, sizeSamples)
+	d.used += size - e.size
+	e.size = size
+}
+
+// len returns the number of keys in the dict.
+func (d *dict) len() int {
+	return d.size
+}
+
+// resize moves all the entries to the new table of the given number of buckets.
+func (d *dict) resize(n int) {
+	old := d.buckets
+	d.buckets = make([]*dictEntry, n)
+
+	for _, e := range old {
+		for e != nil {
+			next := e.next
+			idx := d.bucketIdx(e.key)
+			e.next = d.buckets[idx]
+			d.buckets[idx] = e
+			e = next
+		}
+	}
+}
+
+// scan visits the entries of the bucket pointed by the cursor and returns the cursor of the next
+// bucket, which is 0 once the whole dict is visited.
+//
+// The cursor is incremented on its reversed bits, i.e. from the high order bits of the bucket
+// index. As the buckets are addressed by the low order bits of the hash, a bucket of the table of
+// size 2^n is split into (or merged from) the buckets sharing its n lower bits when the table is
+// resized, and those are exactly the buckets the reversed cursor visits next. Hence, the keys
+// present for the whole iteration are returned at least once, but some may be returned more than once.
+func (d *dict) scan(cursor uint64, fn func(e *dictEntry)) uint64 {
+	mask := uint64(len(d.buckets) - 1)
+	for e := d.buckets[cursor&mask]; e != nil; e = e.next {
+		fn(e)
+	}
+
+	// Set the unmasked bits so that incrementing the reversed cursor carries over to the masked bits
+	cursor |= ^mask
+	cursor = bits.Reverse64(cursor)
+	cursor++
+	cursor = bits.Reverse64(cursor)
+
+	return cursor
+}
+
+// forEach visits all the entries of the dict until fn returns false.
+func (d *dict) forEach(fn func(e *dictEntry) bool) {
+	for _, e := range d.buckets {
+		for ; e != nil; e = e.next {
+			if !fn(e) {
+				return
+			}
+		}
+	}
+}
+
+// random returns a random entry of the dict, if any.
+func (d *dict) random() *dictEntry {
+	if d.size == 0 {
+		return nil
+	}
+
+	// Find a non-empty bucket
+	var head *dictEntry
+	for head == nil {
+		head = d.buckets[rand.IntN(len(d.buckets))]
+	}
+
+	// Pick a random entry out of the bucket's chain
+	var chainLen int
+	for e := head; e != nil; e = e.next {
+		chainLen++
+	}
+
+	e := head
+	for range rand.IntN(chainLen) {
+		e = e.next
+	}
+
+	return e
+}
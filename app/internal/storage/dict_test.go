@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+// scanAll iterates the dict with the cursor, calling between after each step, and returns how many
+// times each key was visited.
+func scanAll(t *testing.T, d *dict, between func(step int)) map[string]int {
+	t.Helper()
+
+	seen := make(map[string]int)
+	cursor, step := uint64(0), 0
+	for {
+		cursor = d.scan(cursor, func(e *dictEntry) {
+			seen[e.key]++
+		})
+		if cursor == 0 {
+			return seen
+		}
+
+		between(step)
+		step++
+		if step > 1<<20 {
+			t.Fatalf("scan didn't complete after %d steps", step)
+		}
+	}
+}
+
+func TestDictScanAcrossResize(t *testing.T) {
+	const persistent = 500
+
+	tests := []struct {
+		name string
+		// churn is the number of extra keys present when the scan starts
+		churn int
+		// between adds or removes extra keys between two steps of the scan
+		between func(d *dict, step int)
+	}{
+		{
+			name:    "no resize",
+			between: func(d *dict, step int) {},
+		},
+		{
+			name: "grow",
+			between: func(d *dict, step int) {
+				if step >= 40 {
+					return
+				}
+				for i := range 100 {
+					d.set(fmt.Sprintf("churn-%d-%d", step, i), []byte("v"))
+				}
+			},
+		},
+		{
+			name:  "shrink",
+			churn: 20000,
+			between: func(d *dict, step int) {
+				for i := step * 400; i < (step+1)*400 && i < 20000; i++ {
+					d.delete(fmt.Sprintf("churn-%d", i))
+				}
+			},
+		},
+		{
+			name:  "grow and shrink",
+			churn: 5000,
+			between: func(d *dict, step int) {
+				if step >= 20 {
+					return
+				}
+				if step%2 == 0 {
+					for i := range 3000 {
+						d.set(fmt.Sprintf("burst-%d", i), []byte("v"))
+					}
+				} else {
+					for i := range 3000 {
+						d.delete(fmt.Sprintf("burst-%d", i))
+					}
+					for i := range 500 {
+						d.delete(fmt.Sprintf("churn-%d", step*500+i))
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDict()
+			for i := range persistent {
+				d.set(fmt.Sprintf("key-%d", i), []byte("v"))
+			}
+			for i := range tt.churn {
+				d.set(fmt.Sprintf("churn-%d", i), []byte("v"))
+			}
+
+			sizes := map[int]bool{len(d.buckets): true}
+			seen := scanAll(t, d, func(step int) {
+				tt.between(d, step)
+				sizes[len(d.buckets)] = true
+			})
+
+			if tt.name != "no resize" && len(sizes) < 2 {
+				t.Fatalf("the dict was never resized during the scan")
+			}
+			for i := range persistent {
+				if key := fmt.Sprintf("key-%d", i); seen[key] == 0 {
+					t.Errorf("scan missed %q, present for the whole iteration", key)
+				}
+			}
+		})
+	}
+}
+
+func TestDictScanWithoutResizeVisitsOnce(t *testing.T) {
+	d := newDict()
+	for i := range 1000 {
+		d.set(fmt.Sprintf("key-%d", i), []byte("v"))
+	}
+
+	seen := scanAll(t, d, func(int) {})
+	if len(seen) != 1000 {
+		t.Fatalf("scan returned %d keys, want 1000", len(seen))
+	}
+	for key, n := range seen {
+		if n != 1 {
+			t.Errorf("scan returned %q %d times, want once", key, n)
+		}
+	}
+}
+
+func TestDictScanEmpty(t *testing.T) {
+	if seen := scanAll(t, newDict(), func(int) {}); len(seen) != 0 {
+		t.Fatalf("scan returned %d keys of the empty dict", len(seen))
+	}
+}
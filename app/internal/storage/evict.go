@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gokv/app/internal/errors"
+)
+
+const (
+	// evictionSamples is the number of keys sampled per database to find the best key to evict
+	evictionSamples = 5
+	// evictionPoolSize is the number of best candidates for eviction kept across the samples
+	evictionPoolSize = 16
+
+	// lfuInitVal is the frequency counter of a new key, so that it isn't evicted right away
+	lfuInitVal = 5
+	// lfuLogFactor controls how many accesses it takes to saturate the frequency counter
+	lfuLogFactor = 10
+	// lfuDecayTime is the number of minutes after which the frequency counter is decremented
+	lfuDecayTime = 1
+)
+
+// EvictionPolicy represents the policy of choosing the keys to evict when the memory limit is reached.
+type EvictionPolicy int
+
+const (
+	NoEviction EvictionPolicy = iota
+	AllKeysLRU
+	AllKeysLFU
+	AllKeysRandom
+	VolatileLRU
+	VolatileLFU
+	VolatileRandom
+	VolatileTTL
+)
+
+var evictionPolicyNames = map[EvictionPolicy]string{
+	NoEviction:     "noeviction",
+	AllKeysLRU:     "allkeys-lru",
+	AllKeysLFU:     "allkeys-lfu",
+	AllKeysRandom:  "allkeys-random",
+	VolatileLRU:    "volatile-lru",
+	VolatileLFU:    "volatile-lfu",
+	VolatileRandom: "volatile-random",
+	VolatileTTL:    "volatile-ttl",
+}
+
+func (p EvictionPolicy) String() string {
+	return evictionPolicyNames[p]
+}
+
+// volatile checks if the policy evicts only the keys having a time-to-live.
+func (p EvictionPolicy) volatile() bool {
+	return p == VolatileLRU || p == VolatileLFU || p == VolatileRandom || p == VolatileTTL
+}
+
+// ParseEvictionPolicy parses the eviction policy out of its name.
+func ParseEvictionPolicy(name string) (EvictionPolicy, error) {
+	for p, pName := range evictionPolicyNames {
+		if pName == name {
+			return p, nil
+		}
+	}
+
+	return NoEviction, fmt.Errorf("invalid eviction policy %q", name)
+}
+
+// evictionCandidate is a key which may be evicted. The higher the score, the better the candidate.
+type evictionCandidate struct {
+	db    int
+	key   string
+	score int64
+}
+
+// Evictor keeps the memory used by the databases within the limit by evicting the keys as per the policy.
+type Evictor struct {
+	// MaxMemory is the memory limit in bytes. There is no limit if it's 0.
+	MaxMemory int64
+	Policy    EvictionPolicy
+
+	mu sync.Mutex
+	// pool holds the best candidates for eviction found so far, sorted by ascending score
+	pool    []evictionCandidate
+	evicted atomic.Int64
+}
+
+// Evict evicts the keys out of the databases until the memory used is within the limit.
+// It returns ErrOOM if the memory can't be freed as per the policy.
+func (e *Evictor) Evict(dbs []*Mem) error {
+	if e.MaxMemory <= 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for UsedMemory(dbs) > e.MaxMemory {
+		if e.Policy == NoEviction {
+			return errors.ErrOOM
+		}
+
+		var evicted bool
+		if e.Policy == AllKeysRandom || e.Policy == VolatileRandom {
+			evicted = e.evictRandom(dbs)
+		} else {
+			evicted = e.evictFromPool(dbs)
+		}
+
+		if !evicted {
+			return errors.ErrOOM
+		}
+		e.evicted.Add(1)
+	}
+
+	return nil
+}
+
+// Evicted returns the number of keys evicted so far.
+func (e *Evictor) Evicted() int64 {
+	return e.evicted.Load()
+}
+
+// evictRandom evicts a random key out of the databases, starting from a random one.
+func (e *Evictor) evictRandom(dbs []*Mem) bool {
+	start := rand.IntN(len(dbs))
+	for i := range dbs {
+		db := dbs[(start+i)%len(dbs)]
+		for _, c := range db.sampleEvictionCandidates(e.Policy, 1) {
+			if db.evictKey(c.key, e.Policy.volatile()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// evictFromPool refills the pool with the sampled keys of every database and evicts the best candidate.
+func (e *Evictor) evictFromPool(dbs []*Mem) bool {
+	for i, db := range dbs {
+		for _, c := range db.sampleEvictionCandidates(e.Policy, evictionSamples) {
+			c.db = i
+			e.addToPool(c)
+		}
+	}
+
+	// The candidates may be gone since they were sampled, so try the next best one until one is evicted
+	for len(e.pool) > 0 {
+		best := e.pool[len(e.pool)-1]
+		e.pool = e.pool[:len(e.pool)-1]
+
+		if best.db < len(dbs) && dbs[best.db].evictKey(best.key, e.Policy.volatile()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addToPool inserts the candidate keeping the pool sorted, dropping the worst one once the pool is full.
+func (e *Evictor) addToPool(c evictionCandidate) {
+	if slices.ContainsFunc(e.pool, func(p evictionCandidate) bool { return p.db == c.db && p.key == c.key }) {
+		return
+	}
+
+	idx, _ := slices.BinarySearchFunc(e.pool, c.score, func(p evictionCandidate, score int64) int {
+		return cmp.Compare(p.score, score)
+	})
+	if len(e.pool) == evictionPoolSize {
+		if idx == 0 {
+			return
+		}
+
+		e.pool = e.pool[1:]
+		idx--
+	}
+
+	e.pool = slices.Insert(e.pool, idx, c)
+}
+
+// UsedMemory returns the approximate memory used by all the databases in bytes.
+func UsedMemory(dbs []*Mem) int64 {
+	var used int64
+	for _, db := range dbs {
+		used += db.UsedMemory()
+	}
+
+	return used
+}
+
+// sampleEvictionCandidates samples the given number of keys which may be evicted as per the policy.
+func (m *Mem) sampleEvictionCandidates(policy EvictionPolicy, samples int) []evictionCandidate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []*dictEntry
+	if policy.volatile() {
+		// The map iteration order is randomized already
+		for key := range m.expires {
+			if len(entries) == samples {
+				break
+			}
+
+			if e := m.mp.find(key); e != nil {
+				entries = append(entries, e)
+			}
+		}
+	} else {
+		for range samples {
+			if e := m.mp.random(); e != nil {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	now := time.Now()
+	candidates := make([]evictionCandidate, 0, len(entries))
+	for _, e := range entries {
+		var score int64
+		switch policy {
+		case AllKeysLRU, VolatileLRU:
+			score = now.UnixMilli() - e.atime.Load()
+		case AllKeysLFU, VolatileLFU:
+			score = 255 - int64(lfuDecrAndReturn(e.lfu.Load(), now))
+		case VolatileTTL:
+			score = math.MaxInt64 - m.expires[e.key].UnixMilli()
+		}
+
+		candidates = append(candidates, evictionCandidate{key: e.key, score: score})
+	}
+
+	return candidates
+}
+
+// evictKey removes the key, if it still exists. If volatile is true, the key is removed only
+// if it still has a time-to-live. It returns whether the key was removed.
+func (m *Mem) evictKey(key string, volatile bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.expires[key]; volatile && !ok {
+		return false
+	}
+	if _, ok := m.mp.get(key); !ok {
+		return false
+	}
+
+	m.deleteKey(key)
+	return true
+}
+
+// touch records the access of the entry for the eviction policies.
+func (e *dictEntry) touch() {
+	now := time.Now()
+	e.atime.Store(now.UnixMilli())
+
+	counter := lfuLogIncr(lfuDecrAndReturn(e.lfu.Load(), now))
+	e.lfu.Store(lfuPack(counter, now))
+}
+
+// lfuPack packs the frequency counter along with the time of its last decrement.
+func lfuPack(counter uint8, now time.Time) uint64 {
+	return uint64(now.Unix()/60)<<8 | uint64(counter)
+}
+
+// lfuDecrAndReturn returns the packed frequency counter decremented by the number of decay periods
+// elapsed since it was last decremented.
+func lfuDecrAndReturn(lfu uint64, now time.Time) uint8 {
+	counter := uint8(lfu & 0xff)
+	elapsed := uint64(now.Unix()/60) - lfu>>8
+
+	periods := elapsed / lfuDecayTime
+	if periods >= uint64(counter) {
+		return 0
+	}
+
+	return counter - uint8(periods)
+}
+
+// lfuLogIncr increments the frequency counter logarithmically, i.e. the higher the counter,
+// the less likely it gets incremented.
+func lfuLogIncr(counter uint8) uint8 {
+	if counter == math.MaxUint8 {
+		return counter
+	}
+
+	baseVal := max(float64(counter)-lfuInitVal, 0)
+	if rand.Float64() < 1/(baseVal*lfuLogFactor+1) {
+		counter++
+	}
+
+	return counter
+}
@@ -0,0 +1,460 @@
+package storage
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"gokv/app/internal/errors"
+)
+
+const (
+	// hashMaxListpackEntries is the number of fields up to which a hash is stored in the compact encoding
+	hashMaxListpackEntries = 128
+	// hashMaxListpackValue is the length of the fields and values up to which a hash is stored in the
+	// compact encoding
+	hashMaxListpackValue = 64
+)
+
+// hash is the hash value. A small hash is stored as a flat list of its fields and values, which is
+// searched linearly, like the "listpack" encoding of Redis. It's converted to a map once it has more
+// than hashMaxListpackEntries fields or a field or value longer than hashMaxListpackValue, and it's
+// never converted back.
+type hash struct {
+	// pairs holds the fields and values alternately, while the hash is compact
+	pairs []string
+	// fields maps the fields with their values, once the hash is converted
+	fields map[string]string
+	// bytes is the total length of the fields and values, to estimate the memory used
+	bytes int64
+	// expires maps the fields having a time-to-live with the time at which they expire
+	expires map[string]time.Time
+	// nextExpire is no later than the time at which the first field expires, so that the expired
+	// fields are looked for only once it has passed
+	nextExpire time.Time
+}
+
+func newHash() *hash {
+	return &hash{}
+}
+
+// Len returns the number of fields of the hash, not counting the expired ones.
+func (h *hash) Len() int {
+	n := len(h.pairs) / 2
+	if h.fields != nil {
+		n = len(h.fields)
+	}
+
+	if len(h.expires) == 0 || time.Now().Before(h.nextExpire) {
+		return n
+	}
+	for field := range h.expires {
+		if h.isExpired(field) {
+			n--
+		}
+	}
+	return n
+}
+
+// isExpired checks if the field has a time-to-live which has already passed.
+func (h *hash) isExpired(field string) bool {
+	at, ok := h.expires[field]
+	return ok && !time.Now().Before(at)
+}
+
+// isCompact returns whether the hash is stored as a flat list.
+func (h *hash) isCompact() bool {
+	return h.fields == nil
+}
+
+// indexOf returns the index of the field in the flat list, or -1 if it's missing.
+func (h *hash) indexOf(field string) int {
+	for i := 0; i < len(h.pairs); i += 2 {
+		if h.pairs[i] == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the value of the field, treating the expired field as missing.
+func (h *hash) get(field string) (string, bool) {
+	if h.isExpired(field) {
+		return "", false
+	}
+
+	if h.fields != nil {
+		val, ok := h.fields[field]
+		return val, ok
+	}
+
+	if i := h.indexOf(field); i != -1 {
+		return h.pairs[i+1], true
+	}
+	return "", false
+}
+
+// set sets the value of the field. It returns true if the field was created.
+func (h *hash) set(field, val string) bool {
+	if h.fields == nil && (len(field) > hashMaxListpackValue || len(val) > hashMaxListpackValue) {
+		h.convert()
+	}
+
+	if h.fields != nil {
+		old, ok := h.fields[field]
+		h.fields[field] = val
+		h.updateBytes(field, old, val, ok)
+		return !ok
+	}
+
+	if i := h.indexOf(field); i != -1 {
+		h.updateBytes(field, h.pairs[i+1], val, true)
+		h.pairs[i+1] = val
+		return false
+	}
+
+	h.pairs = append(h.pairs, field, val)
+	h.updateBytes(field, "", val, false)
+	if h.Len() > hashMaxListpackEntries {
+		h.convert()
+	}
+
+	return true
+}
+
+// updateBytes accounts for the value of the field being replaced, or created if it didn't exist.
+func (h *hash) updateBytes(field, old, val string, existed bool) {
+	h.bytes += int64(len(val) - len(old))
+	if !existed {
+		h.bytes += int64(len(field))
+	}
+}
+
+// delete removes the field along with its time-to-live. It returns true if the field existed.
+func (h *hash) delete(field string) bool {
+	h.persist(field)
+
+	if h.fields != nil {
+		val, ok := h.fields[field]
+		if ok {
+			delete(h.fields, field)
+			h.bytes -= int64(len(field) + len(val))
+		}
+		return ok
+	}
+
+	i := h.indexOf(field)
+	if i == -1 {
+		return false
+	}
+
+	h.bytes -= int64(len(field) + len(h.pairs[i+1]))
+	h.pairs = slices.Delete(h.pairs, i, i+2)
+
+	return true
+}
+
+// forEach calls fn on the fields and values of the hash, skipping the expired ones, until fn
+// returns false.
+func (h *hash) forEach(fn func(field, val string) bool) {
+	if h.fields != nil {
+		for field, val := range h.fields {
+			if !h.isExpired(field) && !fn(field, val) {
+				return
+			}
+		}
+		return
+	}
+
+	for i := 0; i < len(h.pairs); i += 2 {
+		if !h.isExpired(h.pairs[i]) && !fn(h.pairs[i], h.pairs[i+1]) {
+			return
+		}
+	}
+}
+
+// convert converts the compact hash to a map.
+func (h *hash) convert() {
+	h.fields = make(map[string]string, len(h.pairs))
+	for i := 0; i < len(h.pairs); i += 2 {
+		h.fields[h.pairs[i]] = h.pairs[i+1]
+	}
+	h.pairs = nil
+}
+
+// clone returns a copy of the hash.
+func (h *hash) clone() *hash {
+	return &hash{
+		pairs:      slices.Clone(h.pairs),
+		fields:     maps.Clone(h.fields),
+		bytes:      h.bytes,
+		expires:    maps.Clone(h.expires),
+		nextExpire: h.nextExpire,
+	}
+}
+
+// clear drops all the fields of the hash.
+func (h *hash) clear() {
+	clear(h.pairs)
+	clear(h.fields)
+	clear(h.expires)
+	*h = hash{}
+}
+
+// storeHash stores the hash in the key, or removes the key if the hash is empty. Caller must hold
+// the write lock.
+func (m *Mem) storeHash(key string, h *hash) {
+	if h.Len() == 0 {
+		m.deleteKey(key)
+		return
+	}
+
+	m.mp.set(key, h)
+	m.trackFieldExpires(key, h)
+}
+
+// HSet sets the fields of the hash of the key to the values, given as field-value pairs, creating
+// the key if it doesn't exist. The time-to-live of the fields is removed. It returns the number of
+// fields created.
+func (m *Mem) HSet(key string, pairs ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		h = newHash()
+	}
+
+	created := 0
+	for i := 0; i < len(pairs); i += 2 {
+		if h.set(pairs[i], pairs[i+1]) {
+			created++
+		}
+		h.persist(pairs[i])
+	}
+	m.mp.set(key, h)
+
+	return created, nil
+}
+
+// HSetNX sets the field of the hash of the key to the value, only if the field doesn't exist. It
+// returns whether the field was set.
+func (m *Mem) HSetNX(key, field, val string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		h = newHash()
+	} else if _, exists := h.get(field); exists {
+		return false, nil
+	}
+
+	h.set(field, val)
+	m.mp.set(key, h)
+
+	return true, nil
+}
+
+// HGet returns the value of the field of the hash of the key.
+func (m *Mem) HGet(key, field string) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok, err := m.lookupHash(key, false)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	val, ok := h.get(field)
+	return val, ok, nil
+}
+
+// HMGet returns the values of the fields of the hash of the key, nil for the missing ones.
+func (m *Mem) HMGet(key string, fields ...string) ([]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok, err := m.lookupHash(key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]any, len(fields))
+	if !ok {
+		return vals, nil
+	}
+
+	for i, field := range fields {
+		if val, ok := h.get(field); ok {
+			vals[i] = val
+		}
+	}
+
+	return vals, nil
+}
+
+// HDel removes the fields from the hash of the key, removing the key if no field is left. It returns
+// the number of fields removed.
+func (m *Mem) HDel(key string, fields ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	removed := 0
+	for _, field := range fields {
+		if h.delete(field) {
+			removed++
+		}
+	}
+	m.storeHash(key, h)
+
+	return removed, nil
+}
+
+// HGetAll returns the fields and values of the hash of the key, alternately.
+func (m *Mem) HGetAll(key string) ([]any, error) {
+	return m.hashElems(key, true, true)
+}
+
+// HKeys returns the fields of the hash of the key.
+func (m *Mem) HKeys(key string) ([]any, error) {
+	return m.hashElems(key, true, false)
+}
+
+// HVals returns the values of the hash of the key.
+func (m *Mem) HVals(key string) ([]any, error) {
+	return m.hashElems(key, false, true)
+}
+
+// hashElems returns the fields or the values of the hash of the key, or both alternately.
+func (m *Mem) hashElems(key string, fields, vals bool) ([]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok, err := m.lookupHash(key, false)
+	if err != nil || !ok {
+		return []any{}, err
+	}
+
+	elems := make([]any, 0, h.Len()*2)
+	h.forEach(func(field, val string) bool {
+		if fields {
+			elems = append(elems, field)
+		}
+		if vals {
+			elems = append(elems, val)
+		}
+		return true
+	})
+
+	return elems, nil
+}
+
+// HLen returns the number of fields of the hash of the key.
+func (m *Mem) HLen(key string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok, err := m.lookupHash(key, false)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return h.Len(), nil
+}
+
+// HExists returns whether the field exists in the hash of the key.
+func (m *Mem) HExists(key, field string) (bool, error) {
+	_, ok, err := m.HGet(key, field)
+	return ok, err
+}
+
+// HStrlen returns the length of the value of the field of the hash of the key.
+func (m *Mem) HStrlen(key, field string) (int, error) {
+	val, _, err := m.HGet(key, field)
+	return len(val), err
+}
+
+// HIncrBy increments the integer value of the field of the hash of the key by delta, the missing
+// field being set to 0 first. The time-to-live of the field is retained. It returns the value after the increment.
+func (m *Mem) HIncrBy(key, field string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		h = newHash()
+	}
+
+	var cur int64
+	if val, ok := h.get(field); ok {
+		if cur, ok = parseStrictInt(val); !ok {
+			return 0, errors.ErrHashValueNotInt
+		}
+	}
+
+	if (delta > 0 && cur > math.MaxInt64-delta) || (delta < 0 && cur < math.MinInt64-delta) {
+		return 0, errors.ErrIncrOverflow
+	}
+
+	cur += delta
+	h.set(field, strconv.FormatInt(cur, 10))
+	m.mp.set(key, h)
+
+	return cur, nil
+}
+
+// HIncrByFloat increments the numeric value of the field of the hash of the key by delta, the missing
+// field being set to 0 first. Like Redis, the values are added as long doubles. The time-to-live of
+// the field is retained. It returns the value after the increment.
+func (m *Mem) HIncrByFloat(key, field, delta string) (string, error) {
+	incr, ok := parseLongDouble(delta)
+	if !ok {
+		return "", errors.ErrNotAValidFloat
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		h = newHash()
+	}
+
+	cur := newLongDouble()
+	if val, ok := h.get(field); ok {
+		f, ok := parseLongDouble(val)
+		if !ok {
+			return "", errors.ErrHashValueNotFloat
+		}
+		cur = f
+	}
+
+	formatted, ok := addLongDouble(cur, incr)
+	if !ok {
+		return "", errors.ErrIncrNaNOrInf
+	}
+	h.set(field, formatted)
+	m.mp.set(key, h)
+
+	return formatted, nil
+}
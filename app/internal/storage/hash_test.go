@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+
+	"gokv/app/internal/errors"
+)
+
+func TestHIncrByFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		delta   string
+		want    string
+		wantErr error
+	}{
+		{name: "missing field", delta: "1.5", want: "1.5"},
+		{name: "0.1 plus 0.2", initial: "0.1", delta: "0.2", want: "0.3"},
+		{name: "1.1 plus 2.2", initial: "1.1", delta: "2.2", want: "3.3"},
+		{name: "exponent notation", initial: "5.0e3", delta: "2.0e2", want: "5200"},
+		{name: "delta not a float", initial: "1", delta: "abc", wantErr: errors.ErrNotAValidFloat},
+		{name: "value not a float", initial: "abc", delta: "1", wantErr: errors.ErrHashValueNotFloat},
+		{name: "overflow", initial: "1e4932", delta: "1e4932", wantErr: errors.ErrIncrNaNOrInf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			if tt.initial != "" {
+				if _, err := m.HSet("h", "f", tt.initial); err != nil {
+					t.Fatalf("HSet() error = %v", err)
+				}
+			}
+
+			got, err := m.HIncrByFloat("h", "f", tt.delta)
+			if err != tt.wantErr {
+				t.Fatalf("HIncrByFloat() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("HIncrByFloat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
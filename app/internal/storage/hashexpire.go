@@ -0,0 +1,361 @@
+package storage
+
+import "time"
+
+// FieldExpireCond is the condition under which the time-to-live of a hash field is set.
+type FieldExpireCond int
+
+const (
+	FieldExpireAlways FieldExpireCond = iota
+	// FieldExpireNX sets the time-to-live only if the field has none
+	FieldExpireNX
+	// FieldExpireXX sets the time-to-live only if the field has one
+	FieldExpireXX
+	// FieldExpireGT sets the time-to-live only if it's later than the current one, the field without
+	// one never expiring
+	FieldExpireGT
+	// FieldExpireLT sets the time-to-live only if it's earlier than the current one, the field without
+	// one never expiring
+	FieldExpireLT
+)
+
+// The results of changing the time-to-live of a hash field, as replied by the "HEXPIRE" and
+// "HPERSIST" commands.
+const (
+	// FieldMissing is the result for the field, or the key, which doesn't exist
+	FieldMissing = -2
+	// FieldNoTTL is the result of persisting the field which has no time-to-live
+	FieldNoTTL = -1
+	// FieldNotUpdated is the result of the condition not being met
+	FieldNotUpdated = 0
+	// FieldUpdated is the result of the time-to-live being set or removed
+	FieldUpdated = 1
+	// FieldDeleted is the result of the field being removed, as the time-to-live has already passed
+	FieldDeleted = 2
+)
+
+// HSetExOpts is the options of the "HSETEX" command.
+type HSetExOpts struct {
+	// FNX sets the fields only if none of them exists
+	FNX bool
+	// FXX sets the fields only if all of them exist
+	FXX bool
+	// KeepTTL retains the time-to-live of the fields
+	KeepTTL bool
+	// ExpireAt is the time at which the fields expire, if it's not zero
+	ExpireAt time.Time
+}
+
+// setExpire sets the time at which the field expires.
+func (h *hash) setExpire(field string, at time.Time) {
+	if h.expires == nil {
+		h.expires = make(map[string]time.Time)
+	}
+
+	h.expires[field] = at
+	if len(h.expires) == 1 || at.Before(h.nextExpire) {
+		h.nextExpire = at
+	}
+}
+
+// persist removes the time-to-live of the field. It returns true if the field had one.
+func (h *hash) persist(field string) bool {
+	if _, ok := h.expires[field]; !ok {
+		return false
+	}
+
+	delete(h.expires, field)
+	if len(h.expires) == 0 {
+		h.expires = nil
+		h.nextExpire = time.Time{}
+	}
+
+	return true
+}
+
+// deleteExpired removes the expired fields. It returns the number of fields removed.
+func (h *hash) deleteExpired() int {
+	now := time.Now()
+	if len(h.expires) == 0 || now.Before(h.nextExpire) {
+		return 0
+	}
+
+	var (
+		next    time.Time
+		removed int
+	)
+	for field, at := range h.expires {
+		if !now.Before(at) {
+			h.delete(field)
+			removed++
+			continue
+		}
+
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	h.nextExpire = next
+
+	return removed
+}
+
+// lookupHash is like lookupValue for the hashes, treating the hash whose fields have all expired as
+// missing. If write is true, it also removes the expired fields, and the key if no field is left.
+// Caller must hold the lock, the write lock if write is true.
+func (m *Mem) lookupHash(key string, write bool) (*hash, bool, error) {
+	h, ok, err := lookupValue[*hash](m, key, write)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	if write && h.deleteExpired() > 0 {
+		m.storeHash(key, h)
+	}
+	if h.Len() == 0 {
+		return nil, false, nil
+	}
+
+	return h, true, nil
+}
+
+// trackFieldExpires lets the active expire cycle find the key, if it holds a hash having fields with
+// a time-to-live. Caller must hold the write lock.
+func (m *Mem) trackFieldExpires(key string, val any) {
+	if h, ok := val.(*hash); ok && len(h.expires) > 0 {
+		m.fieldExpires[key] = struct{}{}
+	}
+}
+
+// expireFieldsSample removes the expired fields out of a random sample of hashes having fields with
+// a time-to-live, removing the hashes left empty. It returns the number of hashes cleaned up.
+func (m *Mem) expireFieldsSample() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sampled, cleaned int
+	for key := range m.fieldExpires {
+		if sampled == activeExpireSampleSize {
+			break
+		}
+		sampled++
+
+		// The key may have been removed or overwritten since it was tracked
+		e := m.mp.find(key)
+		if e == nil {
+			delete(m.fieldExpires, key)
+			cleaned++
+			continue
+		}
+		h, ok := e.val.(*hash)
+		if !ok || len(h.expires) == 0 {
+			delete(m.fieldExpires, key)
+			cleaned++
+			continue
+		}
+
+		if h.deleteExpired() > 0 {
+			m.storeHash(key, h)
+			cleaned++
+		}
+		if len(h.expires) == 0 {
+			delete(m.fieldExpires, key)
+		}
+	}
+
+	return cleaned
+}
+
+// HExpire sets the time at which the fields of the hash of the key expire, if the condition is met.
+// The fields are removed right away if the time has already passed. It returns the result for each
+// field, FieldMissing for all of them if the key doesn't exist.
+func (m *Mem) HExpire(key string, at time.Time, cond FieldExpireCond, fields ...string) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, len(fields))
+	if !ok {
+		for i := range results {
+			results[i] = FieldMissing
+		}
+		return results, nil
+	}
+
+	now := time.Now()
+	for i, field := range fields {
+		if _, exists := h.get(field); !exists {
+			results[i] = FieldMissing
+			continue
+		}
+
+		cur, hasTTL := h.expires[field]
+		switch {
+		case cond == FieldExpireNX && hasTTL,
+			cond == FieldExpireXX && !hasTTL,
+			cond == FieldExpireGT && (!hasTTL || !at.After(cur)),
+			cond == FieldExpireLT && hasTTL && !at.Before(cur):
+			results[i] = FieldNotUpdated
+		case !now.Before(at):
+			h.delete(field)
+			results[i] = FieldDeleted
+		default:
+			h.setExpire(field, at)
+			results[i] = FieldUpdated
+		}
+	}
+	m.storeHash(key, h)
+
+	return results, nil
+}
+
+// HExpireTime returns the unix time in milliseconds at which each field of the hash of the key
+// expires, FieldNoTTL for the fields without a time-to-live and FieldMissing for the missing ones.
+func (m *Mem) HExpireTime(key string, fields ...string) ([]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h, ok, err := m.lookupHash(key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]int64, len(fields))
+	for i, field := range fields {
+		if !ok {
+			times[i] = FieldMissing
+			continue
+		}
+
+		if _, exists := h.get(field); !exists {
+			times[i] = FieldMissing
+		} else if at, hasTTL := h.expires[field]; hasTTL {
+			times[i] = at.UnixMilli()
+		} else {
+			times[i] = FieldNoTTL
+		}
+	}
+
+	return times, nil
+}
+
+// HPersist removes the time-to-live of the fields of the hash of the key. It returns the result for
+// each field, FieldMissing for all of them if the key doesn't exist.
+func (m *Mem) HPersist(key string, fields ...string) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, len(fields))
+	for i, field := range fields {
+		switch {
+		case !ok:
+			results[i] = FieldMissing
+		case h.persist(field):
+			results[i] = FieldUpdated
+		default:
+			if _, exists := h.get(field); exists {
+				results[i] = FieldNoTTL
+			} else {
+				results[i] = FieldMissing
+			}
+		}
+	}
+	if ok {
+		m.storeHash(key, h)
+	}
+
+	return results, nil
+}
+
+// HGetEx returns the values of the fields of the hash of the key, nil for the missing ones, and sets
+// the time at which the existing fields expire if expireAt isn't zero, removing them if it has
+// already passed. If persist is true, it removes their time-to-live instead.
+func (m *Mem) HGetEx(key string, fields []string, expireAt time.Time, persist bool) ([]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]any, len(fields))
+	if !ok {
+		return vals, nil
+	}
+
+	now := time.Now()
+	for i, field := range fields {
+		val, exists := h.get(field)
+		if !exists {
+			continue
+		}
+		vals[i] = val
+
+		switch {
+		case persist:
+			h.persist(field)
+		case expireAt.IsZero():
+		case !now.Before(expireAt):
+			h.delete(field)
+		default:
+			h.setExpire(field, expireAt)
+		}
+	}
+	m.storeHash(key, h)
+
+	return vals, nil
+}
+
+// HSetEx sets the fields of the hash of the key to the values, given as field-value pairs, creating
+// the key if it doesn't exist, and sets their time-to-live as per the options. Unless KeepTTL is set,
+// the time-to-live of the fields is removed if ExpireAt is zero. It returns whether the fields were
+// set, which they're not if the FNX or FXX condition isn't met.
+func (m *Mem) HSetEx(key string, pairs []string, opts HSetExOpts) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok, err := m.lookupHash(key, true)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		h = newHash()
+	}
+
+	if opts.FNX || opts.FXX {
+		for i := 0; i < len(pairs); i += 2 {
+			if _, exists := h.get(pairs[i]); exists == opts.FNX {
+				return false, nil
+			}
+		}
+	}
+
+	now := time.Now()
+	for i := 0; i < len(pairs); i += 2 {
+		field := pairs[i]
+		h.set(field, pairs[i+1])
+
+		switch {
+		case opts.KeepTTL:
+		case opts.ExpireAt.IsZero():
+			h.persist(field)
+		case !now.Before(opts.ExpireAt):
+			h.delete(field)
+		default:
+			h.setExpire(field, opts.ExpireAt)
+		}
+	}
+	m.storeHash(key, h)
+
+	return true, nil
+}
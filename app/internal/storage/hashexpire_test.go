@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// rawHash returns the hash stored in the key, without looking for its expired fields.
+func rawHash(t *testing.T, m *Mem, key string) *hash {
+	t.Helper()
+
+	e := m.mp.find(key)
+	if e == nil {
+		return nil
+	}
+	h, ok := e.val.(*hash)
+	if !ok {
+		t.Fatalf("key %s holds %T, want a hash", key, e.val)
+	}
+	return h
+}
+
+// hasRawField checks if the field is stored in the hash, even though it has expired.
+func hasRawField(h *hash, field string) bool {
+	if h.fields != nil {
+		_, ok := h.fields[field]
+		return ok
+	}
+	return h.indexOf(field) != -1
+}
+
+// expireField makes the field of the hash of the key expire in the past, as if its time-to-live had
+// just passed.
+func expireField(t *testing.T, m *Mem, key, field string) {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rawHash(t, m, key).setExpire(field, time.Now().Add(-time.Millisecond))
+}
+
+func TestHashExpiredFieldHiddenOnRead(t *testing.T) {
+	m := NewMem()
+	if _, err := m.HSet("h", "a", "1", "b", "2"); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+	expireField(t, m, "h", "a")
+
+	if _, ok, err := m.HGet("h", "a"); err != nil || ok {
+		t.Errorf("HGet() of the expired field = %v, %v, want missing", ok, err)
+	}
+	if n, err := m.HLen("h"); err != nil || n != 1 {
+		t.Errorf("HLen() = %d, %v, want 1", n, err)
+	}
+	if times, err := m.HExpireTime("h", "a", "b"); err != nil || !slices.Equal(times, []int64{FieldMissing, FieldNoTTL}) {
+		t.Errorf("HExpireTime() = %v, %v, want [%d %d]", times, err, FieldMissing, FieldNoTTL)
+	}
+
+	// Reading the hash doesn't remove the field
+	if h := rawHash(t, m, "h"); !hasRawField(h, "a") {
+		t.Error("expired field removed on a read")
+	}
+}
+
+func TestHashExpiredFieldRemovedOnWrite(t *testing.T) {
+	m := NewMem()
+	if _, err := m.HSet("h", "a", "1", "b", "2"); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+	expireField(t, m, "h", "a")
+
+	if n, err := m.HSet("h", "c", "3"); err != nil || n != 1 {
+		t.Fatalf("HSet() = %d, %v, want 1", n, err)
+	}
+
+	h := rawHash(t, m, "h")
+	if hasRawField(h, "a") || len(h.expires) != 0 {
+		t.Errorf("expired field kept on a write, with expires = %v", h.expires)
+	}
+	if n, err := m.HLen("h"); err != nil || n != 2 {
+		t.Errorf("HLen() = %d, %v, want 2", n, err)
+	}
+
+	// The field is created again rather than updated
+	expireField(t, m, "h", "b")
+	if n, err := m.HSet("h", "b", "4"); err != nil || n != 1 {
+		t.Errorf("HSet() of the expired field = %d, %v, want 1", n, err)
+	}
+	if val, ok, err := m.HGet("h", "b"); err != nil || !ok || val != "4" {
+		t.Errorf("HGet() = %q, %v, %v, want 4", val, ok, err)
+	}
+}
+
+func TestHashKeyRemovedWithLastField(t *testing.T) {
+	m := NewMem()
+	if _, err := m.HSet("h", "a", "1", "b", "2"); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+	expireField(t, m, "h", "a")
+	expireField(t, m, "h", "b")
+
+	// The hash is empty to the reads, though the key is only removed on a write
+	if n, err := m.HLen("h"); err != nil || n != 0 {
+		t.Errorf("HLen() = %d, %v, want 0", n, err)
+	}
+
+	results, err := m.HPersist("h", "a")
+	if err != nil || !slices.Equal(results, []int{FieldMissing}) {
+		t.Errorf("HPersist() = %v, %v, want [%d]", results, err, FieldMissing)
+	}
+	if rawHash(t, m, "h") != nil {
+		t.Error("key kept after its last field expired")
+	}
+}
+
+func TestHashKeyRemovedWhenExpiringLastField(t *testing.T) {
+	m := NewMem()
+	if _, err := m.HSet("h", "a", "1"); err != nil {
+		t.Fatalf("HSet() error = %v", err)
+	}
+
+	results, err := m.HExpire("h", time.Now().Add(-time.Second), FieldExpireAlways, "a")
+	if err != nil || !slices.Equal(results, []int{FieldDeleted}) {
+		t.Errorf("HExpire() = %v, %v, want [%d]", results, err, FieldDeleted)
+	}
+	if rawHash(t, m, "h") != nil {
+		t.Error("key kept after its last field was removed")
+	}
+}
+
+func TestHExpireConditions(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	cur := now.Add(time.Hour)
+	later := now.Add(2 * time.Hour)
+	earlier := now.Add(30 * time.Minute)
+
+	tests := []struct {
+		name   string
+		hasTTL bool
+		cond   FieldExpireCond
+		at     time.Time
+		want   int
+		// wantAt is the time at which the field expires afterwards, zero if it has no time-to-live
+		wantAt time.Time
+	}{
+		{name: "always without ttl", cond: FieldExpireAlways, at: later, want: FieldUpdated, wantAt: later},
+		{name: "always with ttl", hasTTL: true, cond: FieldExpireAlways, at: earlier, want: FieldUpdated, wantAt: earlier},
+		{name: "NX without ttl", cond: FieldExpireNX, at: later, want: FieldUpdated, wantAt: later},
+		{name: "NX with ttl", hasTTL: true, cond: FieldExpireNX, at: later, want: FieldNotUpdated, wantAt: cur},
+		{name: "XX without ttl", cond: FieldExpireXX, at: later, want: FieldNotUpdated},
+		{name: "XX with ttl", hasTTL: true, cond: FieldExpireXX, at: later, want: FieldUpdated, wantAt: later},
+		{name: "GT without ttl", cond: FieldExpireGT, at: later, want: FieldNotUpdated},
+		{name: "GT later", hasTTL: true, cond: FieldExpireGT, at: later, want: FieldUpdated, wantAt: later},
+		{name: "GT equal", hasTTL: true, cond: FieldExpireGT, at: cur, want: FieldNotUpdated, wantAt: cur},
+		{name: "GT earlier", hasTTL: true, cond: FieldExpireGT, at: earlier, want: FieldNotUpdated, wantAt: cur},
+		{name: "LT without ttl", cond: FieldExpireLT, at: later, want: FieldUpdated, wantAt: later},
+		{name: "LT earlier", hasTTL: true, cond: FieldExpireLT, at: earlier, want: FieldUpdated, wantAt: earlier},
+		{name: "LT equal", hasTTL: true, cond: FieldExpireLT, at: cur, want: FieldNotUpdated, wantAt: cur},
+		{name: "LT later", hasTTL: true, cond: FieldExpireLT, at: later, want: FieldNotUpdated, wantAt: cur},
+		{name: "past time deletes", hasTTL: true, cond: FieldExpireAlways, at: now.Add(-time.Second), want: FieldDeleted},
+		{name: "past time not meeting NX", hasTTL: true, cond: FieldExpireNX, at: now.Add(-time.Second), want: FieldNotUpdated, wantAt: cur},
+		{name: "past time meeting LT", cond: FieldExpireLT, at: now.Add(-time.Second), want: FieldDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			if _, err := m.HSet("h", "f", "v", "other", "v"); err != nil {
+				t.Fatalf("HSet() error = %v", err)
+			}
+			if tt.hasTTL {
+				if _, err := m.HExpire("h", cur, FieldExpireAlways, "f"); err != nil {
+					t.Fatalf("HExpire() error = %v", err)
+				}
+			}
+
+			results, err := m.HExpire("h", tt.at, tt.cond, "f", "missing")
+			if err != nil {
+				t.Fatalf("HExpire() error = %v", err)
+			}
+			if !slices.Equal(results, []int{tt.want, FieldMissing}) {
+				t.Errorf("HExpire() = %v, want [%d %d]", results, tt.want, FieldMissing)
+			}
+
+			wantTime := int64(FieldNoTTL)
+			switch {
+			case tt.want == FieldDeleted:
+				wantTime = FieldMissing
+			case !tt.wantAt.IsZero():
+				wantTime = tt.wantAt.UnixMilli()
+			}
+			if times, err := m.HExpireTime("h", "f"); err != nil || !slices.Equal(times, []int64{wantTime}) {
+				t.Errorf("HExpireTime() = %v, %v, want [%d]", times, err, wantTime)
+			}
+		})
+	}
+}
+
+func TestHExpireMissingKey(t *testing.T) {
+	m := NewMem()
+	results, err := m.HExpire("h", time.Now().Add(time.Hour), FieldExpireAlways, "a", "b")
+	if err != nil || !slices.Equal(results, []int{FieldMissing, FieldMissing}) {
+		t.Errorf("HExpire() = %v, %v, want [%d %d]", results, err, FieldMissing, FieldMissing)
+	}
+}
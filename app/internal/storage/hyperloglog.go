@@ -0,0 +1,459 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"gokv/app/internal/errors"
+)
+
+// The HyperLogLog is stored as a string in the same format as Redis, so that it can be read and
+// written with the string commands too. It starts with a 16-byte header:
+//
+//	+------+---+-----+----------+
+//	| HYLL | E | N/U | Cardin.  |
+//	+------+---+-----+----------+
+//
+// The magic "HYLL" is followed by the encoding byte, 3 unused bytes, and the cached cardinality as
+// a 64-bit little-endian integer, whose most significant bit is set when the cache is invalid.
+//
+// The dense encoding packs the 16384 registers of 6 bits each, least significant bit first. The
+// sparse encoding is a run-length encoding of the registers with three opcodes:
+//
+//	ZERO:  00xxxxxx          - x+1 (up to 64) registers set to 0
+//	XZERO: 01xxxxxx yyyyyyyy - xy+1 (up to 16384) registers set to 0
+//	VAL:   1vvvvvxx          - x+1 (up to 4) registers set to v+1 (up to 32)
+//
+// The sparse encoding is converted to dense once a register can't be represented, or the string
+// grows past hllSparseMaxBytes.
+const (
+	hllP          = 14
+	hllQ          = 64 - hllP
+	hllRegisters  = 1 << hllP
+	hllBits       = 6
+	hllRegMax     = 1<<hllBits - 1
+	hllHdrSize    = 16
+	hllDenseSize  = hllHdrSize + (hllRegisters*hllBits+7)/8
+	hllAlphaInf   = 0.721347520444481703680
+	hllHashSeed   = 0xadc83b19
+	hllCardOffset = 8
+
+	hllDense  = 0
+	hllSparse = 1
+
+	hllSparseMaxBytes = 3000
+	hllSparseValMax   = 32
+	hllSparseValRun   = 4
+	hllSparseZeroRun  = 64
+	hllSparseXZeroRun = 16384
+)
+
+var hllMagic = []byte("HYLL")
+
+// hllRegs is the registers of a HyperLogLog, one per byte.
+type hllRegs [hllRegisters]uint8
+
+// PFAdd adds the elements to the HyperLogLog of the key, creating it if it doesn't exist. It returns
+// true if the key was created or its approximated cardinality changed.
+func (m *Mem) PFAdd(key string, elems ...string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hll, ok, err := m.lookupHLL(key, true)
+	if err != nil {
+		return false, err
+	}
+
+	updated := !ok
+	if !ok {
+		hll = newSparseHLL()
+	}
+
+	if hll[4] == hllDense {
+		for _, elem := range elems {
+			idx, cnt := hllPatLen([]byte(elem))
+			if cnt > hllDenseGet(hll, idx) {
+				hllDenseSet(hll, idx, cnt)
+				updated = true
+			}
+		}
+	} else if len(elems) > 0 {
+		regs, err := hllRegsOf(hll)
+		if err != nil {
+			return false, err
+		}
+
+		changed := false
+		for _, elem := range elems {
+			idx, cnt := hllPatLen([]byte(elem))
+			if cnt > regs[idx] {
+				regs[idx] = cnt
+				changed = true
+			}
+		}
+
+		if changed {
+			hll = encodeHLL(regs, false)
+			updated = true
+		}
+	}
+
+	if updated {
+		hllInvalidateCache(hll)
+		m.mp.set(key, hll)
+	}
+
+	return updated, nil
+}
+
+// PFCount returns the approximated cardinality of the HyperLogLog of the key, or of the union of the
+// HyperLogLogs of the keys. The missing keys are treated as empty. The cardinality of a single key
+// is cached in its header.
+func (m *Mem) PFCount(keys ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(keys) == 1 {
+		hll, ok, err := m.lookupHLL(keys[0], true)
+		if err != nil || !ok {
+			return 0, err
+		}
+
+		if card := binary.LittleEndian.Uint64(hll[hllCardOffset:]); card&(1<<63) == 0 {
+			return int64(card), nil
+		}
+
+		regs, err := hllRegsOf(hll)
+		if err != nil {
+			return 0, err
+		}
+
+		card := hllCount(regs)
+		binary.LittleEndian.PutUint64(hll[hllCardOffset:], uint64(card))
+		m.mp.set(keys[0], hll)
+
+		return card, nil
+	}
+
+	var union hllRegs
+	for _, key := range keys {
+		if _, err := m.mergeHLL(&union, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return hllCount(&union), nil
+}
+
+// PFMerge stores the union of the HyperLogLogs of the source keys, and of the destination key if it
+// exists, in the destination key, retaining its time-to-live. The result is dense if any of the
+// inputs is.
+func (m *Mem) PFMerge(dst string, srcs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		union hllRegs
+		dense bool
+	)
+	for _, key := range append([]string{dst}, srcs...) {
+		isDense, err := m.mergeHLL(&union, key)
+		if err != nil {
+			return err
+		}
+		dense = dense || isDense
+	}
+
+	hll := encodeHLL(&union, dense)
+	hllInvalidateCache(hll)
+	// Only the value is overwritten, as the time-to-live of the destination key is retained
+	m.mp.set(dst, hll)
+
+	return nil
+}
+
+// mergeHLL sets the registers to the maximum of themselves and the registers of the HyperLogLog of
+// the key, if it exists. It returns whether the HyperLogLog is dense. Caller must hold the lock.
+func (m *Mem) mergeHLL(regs *hllRegs, key string) (bool, error) {
+	hll, ok, err := m.lookupHLL(key, true)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	other, err := hllRegsOf(hll)
+	if err != nil {
+		return false, err
+	}
+
+	for i, reg := range other {
+		regs[i] = max(regs[i], reg)
+	}
+
+	return hll[4] == hllDense, nil
+}
+
+// lookupHLL returns the HyperLogLog of the key. Caller must hold the lock.
+func (m *Mem) lookupHLL(key string, write bool) ([]byte, bool, error) {
+	b, ok, err := m.lookupBitmap(key, write)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	if len(b) < hllHdrSize || !bytes.Equal(b[:4], hllMagic) || b[4] > hllSparse ||
+		(b[4] == hllDense && len(b) != hllDenseSize) {
+		return nil, false, errors.ErrNotHLL
+	}
+
+	return b, true, nil
+}
+
+// newSparseHLL returns an empty HyperLogLog with the sparse encoding.
+func newSparseHLL() []byte {
+	return encodeHLL(&hllRegs{}, false)
+}
+
+// hllInvalidateCache marks the cached cardinality of the HyperLogLog as invalid.
+func hllInvalidateCache(hll []byte) {
+	hll[hllCardOffset+7] |= 1 << 7
+}
+
+// hllPatLen returns the register of the element, and the length of the pattern 000..1 of its hash,
+// which is the count of the trailing zeros plus one of the hash bits after the register index.
+func hllPatLen(elem []byte) (int, uint8) {
+	hash := murmurHash64A(elem, hllHashSeed)
+	idx := int(hash & (hllRegisters - 1))
+
+	// Set the bit past the Q bits, so that the count is at most Q+1
+	hash >>= hllP
+	hash |= 1 << hllQ
+
+	var cnt uint8 = 1
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		cnt++
+	}
+
+	return idx, cnt
+}
+
+// hllDenseGet returns the register of the dense HyperLogLog.
+func hllDenseGet(hll []byte, idx int) uint8 {
+	regs := hll[hllHdrSize:]
+	byteIdx, fb := idx*hllBits/8, uint(idx*hllBits&7)
+
+	v := regs[byteIdx] >> fb
+	if byteIdx+1 < len(regs) {
+		v |= regs[byteIdx+1] << (8 - fb)
+	}
+
+	return v & hllRegMax
+}
+
+// hllDenseSet sets the register of the dense HyperLogLog.
+func hllDenseSet(hll []byte, idx int, val uint8) {
+	regs := hll[hllHdrSize:]
+	byteIdx, fb := idx*hllBits/8, uint(idx*hllBits&7)
+
+	regs[byteIdx] &^= hllRegMax << fb
+	regs[byteIdx] |= val << fb
+	if byteIdx+1 < len(regs) {
+		regs[byteIdx+1] &^= hllRegMax >> (8 - fb)
+		regs[byteIdx+1] |= val >> (8 - fb)
+	}
+}
+
+// hllRegsOf decodes the registers of the HyperLogLog.
+func hllRegsOf(hll []byte) (*hllRegs, error) {
+	regs := &hllRegs{}
+
+	if hll[4] == hllDense {
+		for i := range regs {
+			regs[i] = hllDenseGet(hll, i)
+		}
+		return regs, nil
+	}
+
+	idx := 0
+	for p := hllHdrSize; p < len(hll); p++ {
+		op := hll[p]
+
+		var run int
+		switch {
+		case op&0xc0 == 0x00: // ZERO
+			run = int(op&0x3f) + 1
+		case op&0xc0 == 0x40: // XZERO
+			if p++; p == len(hll) {
+				return nil, errors.ErrHLLCorrupted
+			}
+			run = int(op&0x3f)<<8 | int(hll[p]) + 1
+		default: // VAL
+			run = int(op&0x3) + 1
+			if idx+run > hllRegisters {
+				return nil, errors.ErrHLLCorrupted
+			}
+			val := (op>>2)&0x1f + 1
+			for i := range run {
+				regs[idx+i] = val
+			}
+		}
+
+		idx += run
+	}
+
+	// The runs must cover exactly all the registers
+	if idx != hllRegisters {
+		return nil, errors.ErrHLLCorrupted
+	}
+
+	return regs, nil
+}
+
+// encodeHLL returns the HyperLogLog of the registers. It's encoded as sparse unless dense is true,
+// or the sparse encoding can't hold the registers.
+func encodeHLL(regs *hllRegs, dense bool) []byte {
+	if !dense {
+		if hll := encodeSparseHLL(regs); hll != nil {
+			return hll
+		}
+	}
+
+	hll := make([]byte, hllDenseSize)
+	copy(hll, hllMagic)
+	hll[4] = hllDense
+	for i, reg := range regs {
+		if reg != 0 {
+			hllDenseSet(hll, i, reg)
+		}
+	}
+
+	return hll
+}
+
+// encodeSparseHLL returns the sparse HyperLogLog of the registers, or nil if a register is too large
+// for it or it would be larger than hllSparseMaxBytes.
+func encodeSparseHLL(regs *hllRegs) []byte {
+	hll := make([]byte, hllHdrSize, 64)
+	copy(hll, hllMagic)
+	hll[4] = hllSparse
+
+	for i := 0; i < hllRegisters; {
+		val, run := regs[i], 1
+		for i+run < hllRegisters && regs[i+run] == val {
+			run++
+		}
+		i += run
+
+		if val > hllSparseValMax {
+			return nil
+		}
+
+		for run > 0 {
+			switch {
+			case val != 0:
+				n := min(run, hllSparseValRun)
+				hll = append(hll, 0x80|(val-1)<<2|byte(n-1))
+				run -= n
+			case run > hllSparseZeroRun:
+				n := min(run, hllSparseXZeroRun)
+				hll = append(hll, 0x40|byte((n-1)>>8), byte(n-1))
+				run -= n
+			default:
+				hll = append(hll, byte(run-1))
+				run = 0
+			}
+		}
+
+		if len(hll) > hllSparseMaxBytes {
+			return nil
+		}
+	}
+
+	return hll
+}
+
+// hllCount returns the approximated cardinality of the registers, with the estimator of Otmar Ertl's
+// "New cardinality estimation algorithms for HyperLogLog sketches", as Redis does.
+func hllCount(regs *hllRegs) int64 {
+	var histo [64]int
+	for _, reg := range regs {
+		histo[reg]++
+	}
+
+	m := float64(hllRegisters)
+	z := m * hllTau((m-float64(histo[hllQ+1]))/m)
+	for j := hllQ; j >= 1; j-- {
+		z += float64(histo[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histo[0])/m)
+
+	return int64(math.Round(hllAlphaInf * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// murmurHash64A is the 64-bit MurmurHash2 by Austin Appleby, reading the data as little-endian.
+func murmurHash64A(data []byte, seed uint64) uint64 {
+	const (
+		m = 0xc6a4a7935bd1e995
+		r = 47
+	)
+
+	h := seed ^ uint64(len(data))*m
+
+	for len(data) >= 8 {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+
+		h ^= k
+		h *= m
+		data = data[8:]
+	}
+
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * i)
+		}
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+
+	return h
+}
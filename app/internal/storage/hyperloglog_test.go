@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"gokv/app/internal/errors"
+)
+
+func TestHLLEmptySparse(t *testing.T) {
+	// The header with a valid cached cardinality of 0, followed by a single XZERO of 16384 registers
+	want := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff")
+	if got := newSparseHLL(); !bytes.Equal(got, want) {
+		t.Fatalf("newSparseHLL() = %q, want %q", got, want)
+	}
+}
+
+func TestHLLEncodingRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+
+	tests := []struct {
+		name string
+		// set is the number of registers set, at random positions
+		set int
+		// maxVal is the largest value of a set register
+		maxVal    uint8
+		dense     bool
+		wantDense bool
+	}{
+		{name: "empty sparse", set: 0, maxVal: 1},
+		{name: "few sparse", set: 20, maxVal: hllSparseValMax},
+		{name: "many sparse", set: 500, maxVal: 4},
+		{name: "value too large for sparse", set: 20, maxVal: hllRegMax, wantDense: true},
+		{name: "too large for sparse", set: hllRegisters, maxVal: hllSparseValMax, wantDense: true},
+		{name: "empty dense", set: 0, maxVal: 1, dense: true, wantDense: true},
+		{name: "full dense", set: hllRegisters, maxVal: hllRegMax, dense: true, wantDense: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := &hllRegs{}
+			for range tt.set {
+				regs[rnd.IntN(hllRegisters)] = uint8(rnd.IntN(int(tt.maxVal))) + 1
+			}
+			// Make sure a register can't be represented by the sparse encoding
+			if tt.wantDense && !tt.dense && tt.maxVal > hllSparseValMax {
+				regs[0] = tt.maxVal
+			}
+
+			hll := encodeHLL(regs, tt.dense)
+			if isDense := hll[4] == hllDense; isDense != tt.wantDense {
+				t.Fatalf("encodeHLL() dense = %v, want %v", isDense, tt.wantDense)
+			}
+			if tt.wantDense && len(hll) != hllDenseSize {
+				t.Fatalf("encodeHLL() dense length = %d, want %d", len(hll), hllDenseSize)
+			}
+			if !tt.wantDense && len(hll) > hllSparseMaxBytes {
+				t.Fatalf("encodeHLL() sparse length = %d, want at most %d", len(hll), hllSparseMaxBytes)
+			}
+
+			decoded, err := hllRegsOf(hll)
+			if err != nil {
+				t.Fatalf("hllRegsOf() error = %v", err)
+			}
+			if *decoded != *regs {
+				t.Fatalf("hllRegsOf() doesn't return the encoded registers")
+			}
+		})
+	}
+}
+
+func TestHLLCorruptedSparse(t *testing.T) {
+	hdr := string(newSparseHLL()[:hllHdrSize])
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "runs too short", data: "\x3f"},
+		{name: "runs too long", data: "\x7f\xff\x00"},
+		{name: "truncated XZERO", data: "\x7f"},
+		{name: "VAL past the registers", data: "\x7f\xfe\x83"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := hllRegsOf([]byte(hdr + tt.data)); err != errors.ErrHLLCorrupted {
+				t.Fatalf("hllRegsOf() error = %v, want %v", err, errors.ErrHLLCorrupted)
+			}
+		})
+	}
+}
+
+func TestPFCount(t *testing.T) {
+	m := NewMem()
+	if _, err := m.PFAdd("small", "a", "b", "c", "d", "e", "f", "g"); err != nil {
+		t.Fatalf("PFAdd() error = %v", err)
+	}
+	if n, err := m.PFCount("small"); err != nil || n != 7 {
+		t.Fatalf("PFCount() = %d, %v, want 7", n, err)
+	}
+
+	// Grow past the sparse encoding, checking the estimate on the way
+	added := 0
+	for _, card := range []int{100, 1000, 10000, 100000} {
+		elems := make([]string, 0, card-added)
+		for ; added < card; added++ {
+			elems = append(elems, fmt.Sprintf("elem-%d", added))
+		}
+		if _, err := m.PFAdd("big", elems...); err != nil {
+			t.Fatalf("PFAdd() error = %v", err)
+		}
+
+		n, err := m.PFCount("big")
+		if err != nil {
+			t.Fatalf("PFCount() error = %v", err)
+		}
+		// The standard error is 0.81%, so allow for well over 3 of them
+		if math.Abs(float64(n)-float64(card)) > float64(card)*0.03 {
+			t.Errorf("PFCount() = %d, want about %d", n, card)
+		}
+	}
+
+	hll, _, _ := m.Get("big")
+	if hll[4] != hllDense {
+		t.Errorf("HyperLogLog of %d elements isn't dense", added)
+	}
+	if updated, _ := m.PFAdd("big", "elem-0"); updated {
+		t.Errorf("PFAdd() of an added element updated the HyperLogLog")
+	}
+}
+
+func TestPFMerge(t *testing.T) {
+	m := NewMem()
+	for i := range 3000 {
+		key := fmt.Sprintf("hll-%d", i%3)
+		if _, err := m.PFAdd(key, fmt.Sprintf("elem-%d", i)); err != nil {
+			t.Fatalf("PFAdd() error = %v", err)
+		}
+	}
+
+	if err := m.PFMerge("union", "hll-0", "hll-1", "hll-2", "missing"); err != nil {
+		t.Fatalf("PFMerge() error = %v", err)
+	}
+
+	merged, err := m.PFCount("union")
+	if err != nil {
+		t.Fatalf("PFCount() error = %v", err)
+	}
+	union, err := m.PFCount("hll-0", "hll-1", "hll-2")
+	if err != nil {
+		t.Fatalf("PFCount() error = %v", err)
+	}
+	if merged != union || math.Abs(float64(merged)-3000) > 90 {
+		t.Errorf("PFCount() of the merge = %d and of the union = %d, want both about 3000", merged, union)
+	}
+
+	if _, err := m.SetRange("str", 0, "not a hll"); err != nil {
+		t.Fatalf("SetRange() error = %v", err)
+	}
+	if err := m.PFMerge("union", "str"); err != errors.ErrNotHLL {
+		t.Errorf("PFMerge() of a string error = %v, want %v", err, errors.ErrNotHLL)
+	}
+}
+
+func TestPFMergeRetainsTTL(t *testing.T) {
+	m := NewMem()
+	if _, err := m.PFAdd("dst", "a"); err != nil {
+		t.Fatalf("PFAdd() error = %v", err)
+	}
+	if _, err := m.PFAdd("src", "b", "c"); err != nil {
+		t.Fatalf("PFAdd() error = %v", err)
+	}
+	at := time.Now().Add(time.Hour)
+	m.expires["dst"] = at
+
+	if err := m.PFMerge("dst", "src"); err != nil {
+		t.Fatalf("PFMerge() error = %v", err)
+	}
+
+	if got, ok := m.expires["dst"]; !ok || !got.Equal(at) {
+		t.Errorf("expire time after PFMerge() = %v, %v, want %v", got, ok, at)
+	}
+	if n, err := m.PFCount("dst"); err != nil || n != 3 {
+		t.Errorf("PFCount() = %d, %v, want 3", n, err)
+	}
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"maps"
+
+	"gokv/app/internal/errors"
+)
+
+// randomKeyAttempts is the number of random keys tried to find one which isn't expired
+const randomKeyAttempts = 100
+
+// copyValue returns a deep copy of the value so that both copies can be modified independently.
+func copyValue(val any) any {
+	switch v := val.(type) {
+	case []byte:
+		return bytes.Clone(v)
+	case *quicklist:
+		return v.clone()
+	case *hash:
+		return v.clone()
+	case *set:
+		return v.clone()
+	case *zset:
+		return v.clone()
+	case Stream:
+		stream := make(Stream, len(v))
+		for i, elem := range v {
+			stream[i] = &StreamElem{
+				ID:    elem.ID,
+				Pairs: maps.Clone(elem.Pairs),
+			}
+		}
+		return stream
+	default:
+		return v
+	}
+}
+
+// signalKeyAsReady serves the connections blocked on the key, if a list was moved onto it.
+// Caller must hold the write lock.
+func (m *Mem) signalKeyAsReady(key string, val any) {
+	if _, ok := val.(*quicklist); ok {
+		m.signalListReady(key)
+	}
+}
+
+// Del removes the given keys and returns the number of keys that were removed.
+func (m *Mem) Del(keys ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for _, key := range keys {
+		if _, ok := m.lookupKeyWrite(key); !ok {
+			continue
+		}
+
+		m.deleteKey(key)
+		removed++
+	}
+
+	return removed
+}
+
+// Unlink is like Del, but the large values are released in background.
+func (m *Mem) Unlink(keys ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int
+	for _, key := range keys {
+		if _, ok := m.lookupKeyWrite(key); !ok {
+			continue
+		}
+
+		val, _ := m.deleteKey(key)
+		lazyFree(val)
+		removed++
+	}
+
+	return removed
+}
+
+// Exists returns the number of the given keys which exist. A key mentioned multiple times is counted multiple times.
+func (m *Mem) Exists(keys ...string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var cnt int
+	for _, key := range keys {
+		if !m.isExpired(key) && m.mp.find(key) != nil {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+// Rename renames the key to newKey along with its time-to-live, overwriting the existing newKey.
+// If nx is true, it doesn't rename if newKey already exists. It returns whether the key was renamed.
+func (m *Mem) Rename(key, newKey string, nx bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	val, ok := m.lookupKeyWrite(key)
+	if !ok {
+		return false, errors.ErrNoSuchKey
+	}
+
+	if _, ok := m.lookupKeyWrite(newKey); ok && nx {
+		return false, nil
+	}
+	if key == newKey {
+		return true, nil
+	}
+
+	at, hasTTL := m.expires[key]
+	m.deleteKey(key)
+	m.deleteKey(newKey)
+
+	m.mp.set(newKey, val)
+	if hasTTL {
+		m.expires[newKey] = at
+	}
+
+	m.trackFieldExpires(newKey, val)
+	m.signalKeyAsReady(newKey, val)
+
+	return true, nil
+}
+
+// Touch marks the given keys as accessed and returns the number of them which exist.
+func (m *Mem) Touch(keys ...string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var cnt int
+	for _, key := range keys {
+		if _, ok := m.lookupKey(key); ok {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+// DBSize returns the number of keys in the store.
+func (m *Mem) DBSize() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.mp.len()
+}
+
+// RandomKey returns a random key out of the store, if any.
+func (m *Mem) RandomKey() (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Give up on a few attempts, in case most of the keys are expired but not yet removed
+	for range randomKeyAttempts {
+		e := m.mp.random()
+		if e == nil {
+			break
+		}
+
+		if !m.isExpired(e.key) {
+			return e.key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,58 @@
+package storage
+
+// lazyfreeThreshold is the number of elements above which a value is released in background.
+const lazyfreeThreshold = 64
+
+// freeEffort returns the approximate amount of work needed to release the value.
+func freeEffort(val any) int {
+	switch v := val.(type) {
+	case *quicklist:
+		return v.Len()
+	case *hash:
+		return v.Len()
+	case *set:
+		return v.Len()
+	case *zset:
+		return v.Len()
+	case Stream:
+		return len(v)
+	default:
+		return 1
+	}
+}
+
+// lazyFree releases the value which is no longer reachable from the store. The memory is reclaimed
+// by the garbage collector, hence the large containers are only cleared in background so that
+// dropping their elements one by one doesn't happen on the caller's path.
+func lazyFree(val any) {
+	if freeEffort(val) <= lazyfreeThreshold {
+		return
+	}
+
+	go freeValue(val)
+}
+
+// freeValue drops the references held by the value.
+func freeValue(val any) {
+	switch v := val.(type) {
+	case *quicklist:
+		v.clear()
+	case *hash:
+		v.clear()
+	case *set:
+		v.clear()
+	case *zset:
+		v.clear()
+	case Stream:
+		clear(v)
+	}
+}
+
+// freeDict drops the references held by all the values of the dict.
+func freeDict(d *dict) {
+	d.forEach(func(e *dictEntry) bool {
+		freeValue(e.val)
+		return true
+	})
+	clear(d.buckets)
+}
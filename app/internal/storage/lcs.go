@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"gokv/app/internal/errors"
+)
+
+// LCSMatch is a range matching in both the strings, which is part of their longest common subsequence.
+type LCSMatch struct {
+	// A holds the start and end offsets of the range in the first string, both inclusive
+	A [2]int
+	// B holds the start and end offsets of the range in the second string, both inclusive
+	B [2]int
+	// Len is the length of the range
+	Len int
+}
+
+// LCSResult is the longest common subsequence of two strings.
+type LCSResult struct {
+	Seq string
+	// Matches holds the matching ranges making up the subsequence, from the last to the first one
+	Matches []LCSMatch
+}
+
+// LCS finds the longest common subsequence of the string values of both the keys, treating a missing
+// key as the empty string. The matching ranges shorter than minMatchLen are left out of the result.
+func (m *Mem) LCS(key1, key2 string, minMatchLen int) (LCSResult, error) {
+	m.mu.RLock()
+	a, err := m.stringOrEmpty(key1)
+	if err != nil {
+		m.mu.RUnlock()
+		return LCSResult{}, err
+	}
+
+	b, err := m.stringOrEmpty(key2)
+	m.mu.RUnlock()
+	if err != nil {
+		return LCSResult{}, err
+	}
+
+	// The table of the lengths takes 4 bytes per pair of the offsets
+	if (int64(len(a))+1)*(int64(len(b))+1)*4 > maxStringSize {
+		return LCSResult{}, errors.ErrLCSTooLarge
+	}
+
+	return lcs(a, b, minMatchLen), nil
+}
+
+// stringOrEmpty returns the copy of the string value of the key, or the empty string if the key
+// doesn't exist. Caller must hold the lock.
+func (m *Mem) stringOrEmpty(key string) (string, error) {
+	val, ok := m.lookupKey(key)
+	if !ok {
+		return "", nil
+	}
+	if !isString(val) {
+		return "", errors.ErrWrongType
+	}
+
+	return stringOf(val), nil
+}
+
+// lcs finds the longest common subsequence of the strings by dynamic programming, and walks the table
+// backward to find the matching ranges.
+func lcs(a, b string, minMatchLen int) LCSResult {
+	// table[i*(len(b)+1)+j] is the length of the subsequence of a[:i] and b[:j]
+	width := len(b) + 1
+	table := make([]uint32, (len(a)+1)*width)
+	at := func(i, j int) uint32 {
+		return table[i*width+j]
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				table[i*width+j] = at(i-1, j-1) + 1
+			} else {
+				table[i*width+j] = max(at(i-1, j), at(i, j-1))
+			}
+		}
+	}
+
+	var (
+		seq     = make([]byte, at(len(a), len(b)))
+		idx     = len(seq)
+		matches []LCSMatch
+		// The current range, which is empty if aStart equals len(a)
+		aStart, aEnd = len(a), 0
+		bStart, bEnd = 0, 0
+	)
+	for i, j := len(a), len(b); i > 0 && j > 0; {
+		emitRange := false
+		if a[i-1] == b[j-1] {
+			seq[idx-1] = a[i-1]
+
+			if aStart == len(a) {
+				aStart, aEnd = i-1, i-1
+				bStart, bEnd = j-1, j-1
+			} else if aStart == i && bStart == j {
+				// Extend the range backward as it's contiguous
+				aStart--
+				bStart--
+			} else {
+				emitRange = true
+			}
+
+			// Emit the range once either string is walked through
+			if aStart == 0 || bStart == 0 {
+				emitRange = true
+			}
+			idx--
+			i--
+			j--
+		} else {
+			// Move towards the longer subsequence
+			if at(i-1, j) > at(i, j-1) {
+				i--
+			} else {
+				j--
+			}
+
+			if aStart != len(a) {
+				emitRange = true
+			}
+		}
+
+		if emitRange {
+			if matchLen := aEnd - aStart + 1; matchLen >= minMatchLen {
+				matches = append(matches, LCSMatch{
+					A:   [2]int{aStart, aEnd},
+					B:   [2]int{bStart, bEnd},
+					Len: matchLen,
+				})
+			}
+
+			aStart = len(a)
+		}
+	}
+
+	return LCSResult{
+		Seq:     string(seq),
+		Matches: matches,
+	}
+}
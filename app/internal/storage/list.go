@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"time"
+
+	"gokv/app/internal/errors"
+)
+
+// ListSide is the end of a list where the elements are pushed or popped.
+type ListSide int
+
+const (
+	ListLeft ListSide = iota
+	ListRight
+)
+
+// LposOpts is the options of the "LPOS" command.
+type LposOpts struct {
+	// Rank is the match to start from, the negative ranks searching from the tail. It must not be 0
+	Rank int
+	// Count is the number of matches to return, 0 returning all of them
+	Count int
+	// MaxLen is the number of elements to compare, 0 comparing all of them
+	MaxLen int
+}
+
+// push adds the values to the head or tail of the list of the key. Unless onlyExisting is true, the
+// list is created if it doesn't exist. It returns the length of the list after the push, 0 if
+// nothing was pushed.
+func (m *Mem) push(key string, vals []string, head, onlyExisting bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if onlyExisting {
+			return 0, nil
+		}
+		ql = newQuicklist()
+	}
+
+	for _, val := range vals {
+		if head {
+			ql.pushHead(val)
+		} else {
+			ql.pushTail(val)
+		}
+	}
+	m.mp.set(key, ql)
+
+	// The waiting connections may pop the pushed elements right away
+	listLen := ql.Len()
+	m.signalListReady(key)
+
+	return listLen, nil
+}
+
+// Rpushx appends the values to the list of the key only if it exists.
+func (m *Mem) Rpushx(key string, vals ...string) (int, error) {
+	return m.push(key, vals, false, true)
+}
+
+// Lpushx prepends the values to the list of the key only if it exists.
+func (m *Mem) Lpushx(key string, vals ...string) (int, error) {
+	return m.push(key, vals, true, true)
+}
+
+// Rpop removes and returns up to remCnt elements from the tail of the list of the key, the last
+// element first. It returns nil if the key doesn't exist.
+func (m *Mem) Rpop(key string, remCnt int) ([]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pop(key, ListRight, remCnt)
+}
+
+// pop removes and returns up to remCnt elements from the given side of the list of the key, in the
+// order they're popped. It returns nil if the key doesn't exist. Caller must hold the write lock.
+func (m *Mem) pop(key string, side ListSide, remCnt int) ([]any, error) {
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	removed := make([]any, min(remCnt, ql.Len()))
+	for i := range removed {
+		if side == ListLeft {
+			removed[i] = ql.popHead()
+		} else {
+			removed[i] = ql.popTail()
+		}
+	}
+
+	if ql.Len() == 0 {
+		m.deleteKey(key)
+	} else {
+		m.mp.set(key, ql)
+	}
+
+	return removed, nil
+}
+
+// Lmpop pops up to count elements from the given side of the first non-empty list of the keys. It
+// returns nil if all of them are empty.
+func (m *Mem) Lmpop(keys []string, side ListSide, count int) (*PoppedList, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		removed, err := m.pop(key, side, count)
+		if err != nil {
+			return nil, err
+		}
+		if removed != nil {
+			return &PoppedList{Key: key, Vals: removed}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Lindex returns the element at the index of the list of the key, the negative indexes counting
+// from the tail. It returns false if the index is out of range.
+func (m *Mem) Lindex(key string, idx int) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, false)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	idx, ok = listIndex(ql.Len(), idx)
+	if !ok {
+		return "", false, nil
+	}
+
+	return ql.index(idx), true, nil
+}
+
+// Lset sets the element at the index of the list of the key, the negative indexes counting from
+// the tail.
+func (m *Mem) Lset(key string, idx int, val string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.ErrNoSuchKey
+	}
+
+	idx, ok = listIndex(ql.Len(), idx)
+	if !ok {
+		return errors.ErrIndexOutOfRange
+	}
+
+	ql.set(idx, val)
+	m.mp.set(key, ql)
+
+	return nil
+}
+
+// Linsert inserts the value before or after the first occurrence of the pivot in the list of the
+// key. It returns the length of the list after the insert, -1 if the pivot isn't found, or 0 if
+// the key doesn't exist.
+func (m *Mem) Linsert(key string, before bool, pivot string, val string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	pivotIdx := -1
+	ql.forEach(false, func(idx int, elem string) bool {
+		if elem == pivot {
+			pivotIdx = idx
+			return false
+		}
+		return true
+	})
+	if pivotIdx == -1 {
+		return -1, nil
+	}
+
+	if !before {
+		pivotIdx++
+	}
+	ql.insert(pivotIdx, val)
+	m.mp.set(key, ql)
+
+	return ql.Len(), nil
+}
+
+// Lrem removes up to count occurrences of the value from the list of the key, starting from the
+// head, or from the tail if count is negative. A count of 0 removes all of them. It returns the
+// number of removed elements.
+func (m *Mem) Lrem(key string, count int, val string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	// The limit is unsigned, as the min int has no positive counterpart
+	fromTail := count < 0
+	limit := uint(count)
+	if fromTail {
+		limit = -limit
+	}
+
+	removed := 0
+	ql.deleteFunc(fromTail, func(elem string) bool {
+		if elem != val || (limit != 0 && uint(removed) == limit) {
+			return false
+		}
+		removed++
+		return true
+	})
+
+	if ql.Len() == 0 {
+		m.deleteKey(key)
+	} else {
+		m.mp.set(key, ql)
+	}
+
+	return removed, nil
+}
+
+// Ltrim trims the list of the key to the elements between the start and stop indexes, both
+// inclusive, the negative indexes counting from the tail. The key is removed if no element is left.
+func (m *Mem) Ltrim(key string, start, stop int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, true)
+	if err != nil || !ok {
+		return err
+	}
+
+	listLen := ql.Len()
+
+	// Handle negative indexes
+	if start < 0 {
+		start = max(listLen+start, 0)
+	}
+	if stop < 0 {
+		stop += listLen
+	}
+	stop = min(stop, listLen-1)
+
+	if start > stop {
+		m.deleteKey(key)
+		return nil
+	}
+
+	ql.dropTail(listLen - 1 - stop)
+	ql.dropHead(start)
+	m.mp.set(key, ql)
+
+	return nil
+}
+
+// Lpos returns the indexes of the matches of the value in the list of the key, according to the
+// options.
+func (m *Mem) Lpos(key string, val string, opts LposOpts) ([]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ql, ok, err := lookupValue[*quicklist](m, key, false)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	fromTail := opts.Rank < 0
+	skip := opts.Rank - 1
+	if fromTail {
+		skip = -opts.Rank - 1
+	}
+
+	var (
+		matches  []int
+		compared int
+	)
+	ql.forEach(fromTail, func(idx int, elem string) bool {
+		if opts.MaxLen != 0 && compared == opts.MaxLen {
+			return false
+		}
+		compared++
+
+		if elem != val {
+			return true
+		}
+		if skip > 0 {
+			skip--
+			return true
+		}
+
+		matches = append(matches, idx)
+		return opts.Count == 0 || len(matches) < opts.Count
+	})
+
+	return matches, nil
+}
+
+// listIndex returns the index of the list of the given length, the negative indexes counting from
+// the tail. It returns false if the index is out of range.
+func listIndex(listLen, idx int) (int, bool) {
+	if idx < 0 {
+		idx += listLen
+	}
+
+	return idx, idx >= 0 && idx < listLen
+}
+
+// Lmove atomically pops an element from the given side of the source list and pushes it to the
+// given side of the destination list. It returns nil if the source list doesn't exist.
+func (m *Mem) Lmove(src, dst string, from, to ListSide) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lmove(src, dst, from, to)
+}
+
+// lmove is like Lmove. Caller must hold the write lock.
+func (m *Mem) lmove(src, dst string, from, to ListSide) (any, error) {
+	if _, ok, err := lookupValue[*quicklist](m, src, true); err != nil || !ok {
+		return nil, err
+	}
+
+	// Check the type of the destination before modifying the source
+	if _, _, err := lookupValue[*quicklist](m, dst, true); err != nil {
+		return nil, err
+	}
+
+	removed, _ := m.pop(src, from, 1)
+	val := removed[0].(string)
+
+	// Look up the destination after the pop, as it may be the source
+	ql, ok, _ := lookupValue[*quicklist](m, dst, true)
+	if !ok {
+		ql = newQuicklist()
+	}
+	if to == ListLeft {
+		ql.pushHead(val)
+	} else {
+		ql.pushTail(val)
+	}
+	m.mp.set(dst, ql)
+
+	m.signalListReady(dst)
+
+	return val, nil
+}
+
+// Blmove is the blocking variant of Lmove, waiting for the source list to get an element if it
+// doesn't exist. The timeout of 0 waits forever. It returns nil if the timeout passes.
+func (m *Mem) Blmove(src, dst string, from, to ListSide, timeout time.Duration) (any, error) {
+	return m.blockOn([]string{src}, timeout, func(string) (any, error) {
+		return m.lmove(src, dst, from, to)
+	})
+}
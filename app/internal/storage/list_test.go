@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestLrem(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       int
+		wantRemoved int
+		want        []any
+	}{
+		{name: "all", count: 0, wantRemoved: 3, want: []any{"b", "c"}},
+		{name: "from the head", count: 2, wantRemoved: 2, want: []any{"b", "c", "a"}},
+		{name: "from the tail", count: -2, wantRemoved: 2, want: []any{"a", "b", "c"}},
+		{name: "more than present", count: 10, wantRemoved: 3, want: []any{"b", "c"}},
+		{name: "max count", count: math.MaxInt, wantRemoved: 3, want: []any{"b", "c"}},
+		{name: "min count", count: math.MinInt, wantRemoved: 3, want: []any{"b", "c"}},
+		{name: "negated max count", count: -math.MaxInt, wantRemoved: 3, want: []any{"b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			if _, err := m.Rpush("k", "a", "b", "a", "c", "a"); err != nil {
+				t.Fatalf("Rpush() error = %v", err)
+			}
+
+			removed, err := m.Lrem("k", tt.count, "a")
+			if err != nil || removed != tt.wantRemoved {
+				t.Fatalf("Lrem() = %d, %v, want %d", removed, err, tt.wantRemoved)
+			}
+
+			got, err := m.Lrange("k", 0, -1)
+			if err != nil {
+				t.Fatalf("Lrange() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lrange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLremRemovesEmptiedKey(t *testing.T) {
+	m := NewMem()
+	if _, err := m.Rpush("k", "a", "a"); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+
+	if removed, err := m.Lrem("k", math.MinInt, "a"); err != nil || removed != 2 {
+		t.Fatalf("Lrem() = %d, %v, want 2", removed, err)
+	}
+	if n := m.Exists("k"); n != 0 {
+		t.Errorf("Exists() = %d, want 0", n)
+	}
+}
@@ -2,12 +2,23 @@ package storage
 
 import (
 	"fmt"
-	"slices"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"gokv/app/internal/errors"
+)
+
+const (
+	// activeExpireInterval is the interval at which the expired keys are looked up in background
+	activeExpireInterval = 100 * time.Millisecond
+	// activeExpireSampleSize is the number of keys with time-to-live checked per sample
+	activeExpireSampleSize = 20
 )
 
+// memIDs generates the IDs of the stores
+var memIDs atomic.Uint64
+
 // StreamElem represents the single element/item of the stream.
 type StreamElem struct {
 	ID    string
@@ -18,12 +29,14 @@ type StreamElem struct {
 // It maps the ID of stream elements with its struct.
 type Stream []*StreamElem
 
-// ListBlockPop is used to handle the blocking "BLPOP" command.
+// ListBlockPop is used to handle the connections blocked on lists, such as with the "BLPOP" command.
+// It's guarded by the write lock of the store.
 type ListBlockPop struct {
-	// waitQ is map of list key and the list of channels of the connection
-	// waiting for the new element to get inserted in the list
-	waitQ map[string][]chan struct{}
-	mu    sync.Mutex
+	// waitQ is map of list key and the connections waiting for an element to get inserted in the
+	// list, in the order they blocked
+	waitQ map[string][]*listWaiter
+	// ready is the list keys which got elements inserted, whose waiting connections are to be served
+	ready []string
 }
 
 // XreadQ is used to handle the waiting list of xrange with timeout
@@ -37,233 +50,255 @@ type XreadQ struct {
 
 // Mem represents the in-memory storage for Redis data structures.
 type Mem struct {
-	mu  sync.RWMutex
-	mp  map[string]any // TODO: Make sure a key holds the value of only one type. If user tries to change it, they shouldn't be able to do so if the value exists for it.
-	lbp *ListBlockPop
-	xrq *XreadQ
+	// id identifies the store to lock multiple stores in a consistent order
+	id uint64
+	mu sync.RWMutex
+	mp *dict
+	// expires maps the keys having a time-to-live with the time at which they expire
+	expires map[string]time.Time
+	// fieldExpires holds the keys of the hashes having fields with a time-to-live, for the active
+	// expire cycle to find them. It may still hold the keys which were removed or overwritten since
+	fieldExpires map[string]struct{}
+	lbp          *ListBlockPop
+	xrq          *XreadQ
 }
 
 // NewMem creates a new memory storage instance.
 func NewMem() *Mem {
-	return &Mem{
-		mp: make(map[string]any),
+	m := &Mem{
+		id:           memIDs.Add(1),
+		mp:           newDict(),
+		expires:      make(map[string]time.Time),
+		fieldExpires: make(map[string]struct{}),
 		lbp: &ListBlockPop{
-			waitQ: make(map[string][]chan struct{}),
+			waitQ: make(map[string][]*listWaiter),
 		},
 		xrq: &XreadQ{
 			waitQ: make(map[string][]chan int),
 		},
 	}
+
+	go m.activeExpireCycle()
+
+	return m
 }
 
-func (m *Mem) Get(key string) (any, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// isExpired checks if the key has a time-to-live which has already passed. Caller must hold the lock.
+func (m *Mem) isExpired(key string) bool {
+	at, ok := m.expires[key]
+	return ok && !time.Now().Before(at)
+}
 
-	val, ok := m.mp[key]
-	return val, ok
+// lookupKey returns the value of the key, treating the expired key as missing. Caller must hold the lock.
+func (m *Mem) lookupKey(key string) (any, bool) {
+	if m.isExpired(key) {
+		return nil, false
+	}
+
+	e := m.mp.find(key)
+	if e == nil {
+		return nil, false
+	}
+
+	e.touch()
+	return e.val, true
 }
 
-func (m *Mem) Delete(key string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// lookupKeyWrite is like lookupKey, but it also removes the key if it's expired. Caller must hold the write lock.
+func (m *Mem) lookupKeyWrite(key string) (any, bool) {
+	if m.isExpired(key) {
+		m.deleteKey(key)
+		return nil, false
+	}
 
-	delete(m.mp, key)
+	return m.lookupKey(key)
 }
 
-func (m *Mem) Set(key string, val any, exp time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// lookupValue returns the value of the key, if it holds a value of type T. It returns ErrWrongType
+// if the key holds a value of another type. Caller must hold the lock, the write lock if write is true.
+func lookupValue[T any](m *Mem, key string, write bool) (T, bool, error) {
+	var (
+		val any
+		ok  bool
+	)
+	if write {
+		val, ok = m.lookupKeyWrite(key)
+	} else {
+		val, ok = m.lookupKey(key)
+	}
 
-	m.mp[key] = val
+	var zero T
+	if !ok {
+		return zero, false, nil
+	}
 
-	if exp > 0 {
-		go func() {
-			time.Sleep(exp)
-			m.Delete(key)
-		}()
+	typedVal, ok := val.(T)
+	if !ok {
+		return zero, false, errors.ErrWrongType
 	}
+
+	return typedVal, true, nil
 }
 
-// handleListInsert sends the signal to available connections waiting for the element to be inserted
-func (m *Mem) handleListInsert(key string) {
-	// Get the length of the list
-	var listLen int
-	m.mu.RLock()
-	if list, ok := m.mp[key].([]any); ok {
-		listLen = len(list)
+// isString checks if the value is of the string type, which is stored either as bytes or as integer.
+func isString(val any) bool {
+	switch val.(type) {
+	case []byte, int64:
+		return true
+	default:
+		return false
 	}
-	m.mu.RUnlock()
+}
 
-	// Sent the signal to the connections waiting in the queue
-	m.lbp.mu.Lock()
-	defer m.lbp.mu.Unlock()
+// deleteKey removes the key along with its time-to-live. Caller must hold the write lock.
+func (m *Mem) deleteKey(key string) (any, bool) {
+	val, ok := m.mp.delete(key)
+	delete(m.expires, key)
 
-	waitList, ok := m.lbp.waitQ[key]
-	if !ok || len(waitList) <= 0 {
-		return
-	}
+	return val, ok
+}
 
-waitLoop:
-	for i, w := range waitList {
-		select {
-		case w <- struct{}{}:
-			listLen--
-		default:
-		}
+// activeExpireCycle periodically samples the keys and the hash fields having a time-to-live and
+// removes the expired ones, so that the ones which are never accessed again don't stay in memory forever.
+func (m *Mem) activeExpireCycle() {
+	ticker := time.NewTicker(activeExpireInterval)
+	defer ticker.Stop()
 
-		if listLen == 0 { // Break once there are no more elements to be removed
-			if i == -1 || i == len(waitList)-1 {
-				delete(m.lbp.waitQ, key)
-			} else {
-				m.lbp.waitQ[key] = waitList[i+1:]
-			}
-			break waitLoop
+	for range ticker.C {
+		// Keep sampling while a good portion of the sampled keys were expired
+		for m.expireSample() > activeExpireSampleSize/4 {
+		}
+		for m.expireFieldsSample() > activeExpireSampleSize/4 {
 		}
 	}
 }
 
-func (m *Mem) Rpush(key string, vals ...any) int {
+// expireSample removes the expired keys out of a random sample of keys having a time-to-live.
+// It returns the number of keys removed.
+func (m *Mem) expireSample() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	existVals, ok := m.mp[key].([]any)
-	if !ok {
-		existVals = []any{}
-	}
-
-	existVals = append(existVals, vals...)
-	m.mp[key] = existVals
+	now := time.Now()
+	var sampled, expired int
+	for key, at := range m.expires {
+		if sampled == activeExpireSampleSize {
+			break
+		}
+		sampled++
 
-	go m.handleListInsert(key)
+		if !now.Before(at) {
+			m.deleteKey(key)
+			expired++
+		}
+	}
 
-	return len(existVals)
+	return expired
 }
 
-func (m *Mem) Lpush(key string, vals ...any) int {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// Get returns the string value of the key.
+func (m *Mem) Get(key string) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	existVals, ok := m.mp[key].([]any)
+	val, ok := m.lookupKey(key)
 	if !ok {
-		existVals = []any{}
+		return "", false, nil
+	}
+	if !isString(val) {
+		return "", false, errors.ErrWrongType
 	}
 
-	slices.Reverse(vals)
-	existVals = append(vals, existVals...)
-	m.mp[key] = existVals
+	return stringOf(val), true, nil
+}
 
-	go m.handleListInsert(key)
+func (m *Mem) Rpush(key string, vals ...string) (int, error) {
+	return m.push(key, vals, false, false)
+}
 
-	return len(existVals)
+func (m *Mem) Lpush(key string, vals ...string) (int, error) {
+	return m.push(key, vals, true, false)
 }
 
-func (m *Mem) Lrange(key string, start, stop int) []any {
+func (m *Mem) Lrange(key string, start, stop int) ([]any, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	vals, ok := m.mp[key].([]any)
+	ql, ok, err := lookupValue[*quicklist](m, key, false)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
-		return []any{}
+		return []any{}, nil
 	}
 
+	listLen := ql.Len()
+
 	// Handle negative indexes
 	if start < 0 {
-		start = max(len(vals)+start, 0)
+		start = max(listLen+start, 0)
 	}
 	if stop < 0 {
-		stop = max(len(vals)+stop, 0)
+		stop = max(listLen+stop, 0)
 	}
 
-	if start < 0 || stop < 0 || start > stop || start > len(vals) {
-		return []any{}
+	if start < 0 || stop < 0 || start >= listLen || start > stop {
+		return []any{}, nil
 	}
 
-	if stop >= len(vals) {
-		stop = len(vals) - 1
+	if stop >= listLen {
+		stop = listLen - 1
 	}
-	return vals[start : stop+1]
+
+	return ql.rangeElems(start, stop), nil
 }
 
-func (m *Mem) Llen(key string) int {
+func (m *Mem) Llen(key string) (int, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	vals, ok := m.mp[key].([]any)
-	if !ok {
-		return 0
+	ql, ok, err := lookupValue[*quicklist](m, key, false)
+	if err != nil || !ok {
+		return 0, err
 	}
 
-	return len(vals)
+	return ql.Len(), nil
 }
 
-func (m *Mem) Lpop(key string, remCnt int) []any {
+func (m *Mem) Lpop(key string, remCnt int) ([]any, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	vals, ok := m.mp[key].([]any)
-	if !ok || len(vals) == 0 {
-		return nil
-	}
-
-	if remCnt >= len(vals) {
-		delete(m.mp, key)
-		return vals
-	}
-
-	removed := vals[:remCnt]
-	m.mp[key] = vals[remCnt:]
-
-	return removed
-}
-
-func (m *Mem) Blpop(key string, timeout time.Duration) any {
-	// Remove the first element, if present.
-	removed := m.Lpop(key, 1)
-	if removed != nil {
-		return removed[0]
-	}
-
-	// Wait for an element to be present to get removed
-	elemPresSign := make(chan struct{})
-	m.lbp.mu.Lock()
-	m.lbp.waitQ[key] = append(m.lbp.waitQ[key], elemPresSign)
-	m.lbp.mu.Unlock()
-
-	// Handles the no timeout
-	if timeout == 0 {
-		<-elemPresSign
-		return m.Lpop(key, 1)[0]
-	}
-
-	// Handles the timeout
-	for {
-		select {
-		case <-time.After(timeout):
-			return nil
-
-		case <-elemPresSign:
-			return m.Lpop(key, 1)[0]
-		}
-	}
+	return m.pop(key, ListLeft, remCnt)
 }
 
 func (m *Mem) Type(key string) string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	val, ok := m.mp[key]
+	val, ok := m.lookupKey(key)
 	if !ok {
 		return "none"
 	}
 
+	return typeName(val)
+}
+
+// typeName returns the name of the type of the value as reported by the "TYPE" command.
+func typeName(val any) string {
 	switch val.(type) {
-	case string:
+	case []byte, int64:
 		return "string"
 	case Stream:
 		return "stream"
-	case []any:
+	case *quicklist:
 		return "list"
+	case *hash:
+		return "hash"
+	case *set:
+		return "set"
+	case *zset:
+		return "zset"
 	default:
 		return "none"
 	}
@@ -292,16 +327,13 @@ func (m *Mem) Xadd(key string, elem *StreamElem) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	stream, ok := m.mp[key].(Stream)
-	if !ok {
-		stream = make(Stream, 0)
+	stream, _, err := lookupValue[Stream](m, key, true)
+	if err != nil {
+		return "", err
 	}
 
 	// Check if the new ID is valid
-	var (
-		id  string
-		err error
-	)
+	var id string
 	if len(stream) <= 0 {
 		id, err = isValidStreamID(elem.ID, "")
 	} else {
@@ -313,7 +345,7 @@ func (m *Mem) Xadd(key string, elem *StreamElem) (string, error) {
 
 	elem.ID = id
 	stream = append(stream, elem)
-	m.mp[key] = stream
+	m.mp.set(key, stream)
 
 	go m.handleStreamXadd(key, len(stream)-1)
 
@@ -324,9 +356,9 @@ func (m *Mem) Xrange(key, startId, endId string) (Stream, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	stream, ok := m.mp[key].(Stream)
-	if !ok {
-		return nil, nil
+	stream, ok, err := lookupValue[Stream](m, key, false)
+	if err != nil || !ok {
+		return nil, err
 	}
 
 	// Get the start index
@@ -366,9 +398,9 @@ func (m *Mem) xreadForAStream(key, id string, idx int) (Stream, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	stream, ok := m.mp[key].(Stream)
-	if !ok {
-		return nil, nil
+	stream, ok, err := lookupValue[Stream](m, key, false)
+	if err != nil || !ok {
+		return nil, err
 	}
 
 	// Get the index
@@ -463,39 +495,3 @@ func (m *Mem) Xread(keys, ids []string, timeout time.Duration) ([]Stream, error)
 
 	return streams, nil
 }
-
-func (m *Mem) Incr(key string) (any, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	val, ok := m.mp[key]
-	if !ok {
-		m.mp[key] = int64(1)
-		return m.mp[key], nil
-	}
-
-	switch v := val.(type) {
-	case string:
-		ival, err := strconv.ParseInt(v, 10, 64)
-		if err == nil {
-			val = ival + 1
-			break
-		}
-
-		fval, err := strconv.ParseFloat(v, 64)
-		if err == nil {
-			val = fval + 1
-			break
-		}
-
-		return "", fmt.Errorf("ERR value is not an integer or out of range")
-
-	case int64:
-		val = v + 1
-	case float64:
-		val = v + 1
-	}
-
-	m.mp[key] = val
-	return val, nil
-}
@@ -0,0 +1,82 @@
+package storage
+
+const (
+	// entryOverhead is the approximate memory used by the bookkeeping of a single key in bytes
+	entryOverhead = 80
+	// expireOverhead is the approximate memory used by the bookkeeping of the time-to-live of a key in bytes
+	expireOverhead = 48
+	// sizeSamples is the number of elements sampled to estimate the memory used by a collection
+	sizeSamples = 5
+)
+
+// estimateSize returns the approximate memory used by the value in bytes. The size of the collections
+// is estimated out of the given number of their elements, or all of them if samples is 0.
+func estimateSize(val any, samples int) int64 {
+	switch v := val.(type) {
+	case []byte:
+		return 16 + int64(len(v))
+	case int64:
+		return 8
+	case *quicklist:
+		// The total length of the elements is tracked by the list, so it's never sampled
+		return 48 + int64(v.nodes)*quicklistNodeOverhead + int64(v.Len())*16 + v.bytes
+	case *hash:
+		// The compact hash holds two strings per field, and the map about three times as much
+		perField := int64(32)
+		if !v.isCompact() {
+			perField = 96
+		}
+		return 48 + int64(v.Len())*perField + v.bytes + int64(len(v.expires))*expireOverhead
+	case *set:
+		// The intset holds the integers only, and the map a string and its position per member
+		if v.isIntset() {
+			return 16 + int64(v.Len())*8
+		}
+		return 48 + int64(v.Len())*64 + v.bytes
+	case *zset:
+		// Each member has a map entry and a skiplist node of about 1.33 levels
+		return 64 + int64(v.Len())*112 + v.bytes
+	case Stream:
+		return 24 + sampledSize(len(v), samples, func(i int) int64 {
+			size := 8 + stringSize(v[i].ID) + 48
+			for field, value := range v[i].Pairs {
+				size += stringSize(field) + stringSize(value)
+			}
+			return size
+		})
+	default:
+		return 0
+	}
+}
+
+// stringSize returns the approximate memory used by the string in bytes.
+func stringSize(s string) int64 {
+	return 16 + int64(len(s))
+}
+
+// sampledSize estimates the total size of n elements by averaging the size of the given number of
+// samples spread evenly across them. All the elements are measured if samples is 0.
+func sampledSize(n, samples int, sizeOf func(i int) int64) int64 {
+	if samples <= 0 || samples > n {
+		samples = n
+	}
+	if samples == 0 {
+		return 0
+	}
+
+	var total int64
+	step := n / samples
+	for i := range samples {
+		total += sizeOf(i * step)
+	}
+
+	return total * int64(n) / int64(samples)
+}
+
+// UsedMemory returns the approximate memory used by all the keys of the store in bytes.
+func (m *Mem) UsedMemory() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.mp.used
+}
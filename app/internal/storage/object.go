@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"time"
+)
+
+const (
+	// embstrSizeLimit is the length up to which a string is reported as "embstr" encoded, otherwise "raw"
+	embstrSizeLimit = 44
+	// listpackMaxEntries is the number of elements up to which a list is reported as "listpack" encoded
+	listpackMaxEntries = 128
+	// listpackMaxValue is the length of the elements up to which a list is reported as "listpack" encoded
+	listpackMaxValue = 64
+)
+
+// ObjectInfo describes the internal state of the value of a key.
+type ObjectInfo struct {
+	// Encoding is the name of the internal representation of the value
+	Encoding string
+	// IdleTime is the time elapsed since the key was last accessed
+	IdleTime time.Duration
+	// Freq is the logarithmic access frequency counter of the key
+	Freq int
+	// RefCount is the number of references to the value
+	RefCount int
+}
+
+// encoding returns the name of the internal representation of the value.
+func encoding(val any) string {
+	switch v := val.(type) {
+	case int64:
+		return "int"
+	case []byte:
+		if len(v) <= embstrSizeLimit {
+			return "embstr"
+		}
+		return "raw"
+	case *quicklist:
+		if v.Len() > listpackMaxEntries {
+			return "quicklist"
+		}
+
+		enc := "listpack"
+		v.forEach(false, func(_ int, elem string) bool {
+			if len(elem) > listpackMaxValue {
+				enc = "quicklist"
+				return false
+			}
+			return true
+		})
+		return enc
+	case *hash:
+		if v.isCompact() {
+			// Like Redis, the compact hash having fields with a time-to-live is told apart
+			if len(v.expires) > 0 {
+				return "listpackex"
+			}
+			return "listpack"
+		}
+		return "hashtable"
+	case *set:
+		if v.isIntset() {
+			return "intset"
+		}
+		return "hashtable"
+	case *zset:
+		return "skiplist"
+	case Stream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+// Object returns the internal state of the value of the key, without marking the key as accessed.
+func (m *Mem) Object(key string) (ObjectInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isExpired(key) {
+		return ObjectInfo{}, false
+	}
+
+	e := m.mp.find(key)
+	if e == nil {
+		return ObjectInfo{}, false
+	}
+
+	now := time.Now()
+	return ObjectInfo{
+		Encoding: encoding(e.val),
+		IdleTime: now.Sub(time.UnixMilli(e.atime.Load())),
+		Freq:     int(lfuDecrAndReturn(e.lfu.Load(), now)),
+		RefCount: 1,
+	}, true
+}
+
+// MemoryUsage returns the approximate memory used by the key and its value in bytes. The size of
+// the collections is estimated out of the given number of their elements, or all of them if samples is 0.
+func (m *Mem) MemoryUsage(key string, samples int) (int64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isExpired(key) {
+		return 0, false
+	}
+
+	e := m.mp.find(key)
+	if e == nil {
+		return 0, false
+	}
+
+	return entryOverhead + int64(len(e.key)) + estimateSize(e.val, samples), true
+}
+
+// Overhead returns the approximate memory used by the bookkeeping of the keys, and of their time-to-live in bytes.
+func (m *Mem) Overhead() (int64, int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	main := int64(len(m.mp.buckets))*8 + int64(m.mp.len())*entryOverhead
+	expires := int64(len(m.expires)) * expireOverhead
+
+	return main, expires
+}
@@ -0,0 +1,341 @@
+package storage
+
+import "slices"
+
+const (
+	// quicklistNodeSize is the maximum number of elements held by a node of a quicklist
+	quicklistNodeSize = 128
+	// quicklistNodeOverhead is the approximate memory used by the bookkeeping of a node in bytes
+	quicklistNodeOverhead = 48
+)
+
+// quicklist is the list value: a deque of elements stored as a doubly linked list of nodes, each
+// holding up to quicklistNodeSize elements. It pushes and pops in O(1) at both ends, and the memory
+// of the popped elements is released as soon as their node is emptied.
+type quicklist struct {
+	head  *quicklistNode
+	tail  *quicklistNode
+	count int
+	nodes int
+	// bytes is the total length of the elements, to estimate the memory used without walking them
+	bytes int64
+}
+
+type quicklistNode struct {
+	prev  *quicklistNode
+	next  *quicklistNode
+	elems []string
+}
+
+func newQuicklist() *quicklist {
+	return &quicklist{}
+}
+
+// Len returns the number of elements of the list.
+func (ql *quicklist) Len() int {
+	return ql.count
+}
+
+// pushHead inserts the element at the head of the list.
+func (ql *quicklist) pushHead(val string) {
+	if ql.head == nil || len(ql.head.elems) == quicklistNodeSize {
+		ql.linkAfter(nil, &quicklistNode{elems: make([]string, 0, 8)})
+	}
+
+	ql.head.elems = slices.Insert(ql.head.elems, 0, val)
+	ql.count++
+	ql.bytes += int64(len(val))
+}
+
+// pushTail inserts the element at the tail of the list.
+func (ql *quicklist) pushTail(val string) {
+	if ql.tail == nil || len(ql.tail.elems) == quicklistNodeSize {
+		ql.linkAfter(ql.tail, &quicklistNode{elems: make([]string, 0, 8)})
+	}
+
+	ql.tail.elems = append(ql.tail.elems, val)
+	ql.count++
+	ql.bytes += int64(len(val))
+}
+
+// popHead removes and returns the element at the head of the list, which must not be empty.
+func (ql *quicklist) popHead() string {
+	n := ql.head
+	val := n.elems[0]
+	n.elems[0] = ""
+	n.elems = n.elems[1:]
+	ql.removed(n, val)
+
+	return val
+}
+
+// popTail removes and returns the element at the tail of the list, which must not be empty.
+func (ql *quicklist) popTail() string {
+	n := ql.tail
+	val := n.elems[len(n.elems)-1]
+	n.elems[len(n.elems)-1] = ""
+	n.elems = n.elems[:len(n.elems)-1]
+	ql.removed(n, val)
+
+	return val
+}
+
+// removed updates the list after the element was removed from the node, unlinking it if it's empty.
+func (ql *quicklist) removed(n *quicklistNode, val string) {
+	ql.count--
+	ql.bytes -= int64(len(val))
+
+	if len(n.elems) == 0 {
+		ql.unlink(n)
+	}
+}
+
+// linkAfter links the node after the given one, or at the head if it's nil.
+func (ql *quicklist) linkAfter(prev, n *quicklistNode) {
+	n.prev = prev
+	if prev == nil {
+		n.next = ql.head
+		ql.head = n
+	} else {
+		n.next = prev.next
+		prev.next = n
+	}
+
+	if n.next == nil {
+		ql.tail = n
+	} else {
+		n.next.prev = n
+	}
+
+	ql.nodes++
+}
+
+// unlink removes the node from the list.
+func (ql *quicklist) unlink(n *quicklistNode) {
+	if n.prev == nil {
+		ql.head = n.next
+	} else {
+		n.prev.next = n.next
+	}
+
+	if n.next == nil {
+		ql.tail = n.prev
+	} else {
+		n.next.prev = n.prev
+	}
+
+	n.prev, n.next = nil, nil
+	ql.nodes--
+}
+
+// find returns the node holding the element at the index, and the offset of the element in it. The
+// index must be in range. The nodes are walked from the closest end of the list.
+func (ql *quicklist) find(idx int) (*quicklistNode, int) {
+	if idx < ql.count/2 {
+		n := ql.head
+		for idx >= len(n.elems) {
+			idx -= len(n.elems)
+			n = n.next
+		}
+		return n, idx
+	}
+
+	n, idx := ql.tail, ql.count-1-idx
+	for idx >= len(n.elems) {
+		idx -= len(n.elems)
+		n = n.prev
+	}
+	return n, len(n.elems) - 1 - idx
+}
+
+// index returns the element at the index, which must be in range.
+func (ql *quicklist) index(idx int) string {
+	n, off := ql.find(idx)
+	return n.elems[off]
+}
+
+// set replaces the element at the index, which must be in range.
+func (ql *quicklist) set(idx int, val string) {
+	n, off := ql.find(idx)
+	ql.bytes += int64(len(val) - len(n.elems[off]))
+	n.elems[off] = val
+}
+
+// insert inserts the element before the one at the index, or at the tail if the index is the length
+// of the list. The node holding it is split in half once it's full.
+func (ql *quicklist) insert(idx int, val string) {
+	if idx == ql.count {
+		ql.pushTail(val)
+		return
+	}
+
+	n, off := ql.find(idx)
+	n.elems = slices.Insert(n.elems, off, val)
+	ql.count++
+	ql.bytes += int64(len(val))
+
+	if len(n.elems) > quicklistNodeSize {
+		half := len(n.elems) / 2
+		ql.linkAfter(n, &quicklistNode{elems: slices.Clone(n.elems[half:])})
+		clear(n.elems[half:])
+		n.elems = n.elems[:half]
+	}
+}
+
+// rangeElems returns a copy of the elements between the start and stop indexes, both inclusive,
+// which must be in range.
+func (ql *quicklist) rangeElems(start, stop int) []any {
+	result := make([]any, 0, stop-start+1)
+
+	n, off := ql.find(start)
+	for len(result) < cap(result) {
+		for _, val := range n.elems[off:min(len(n.elems), off+cap(result)-len(result))] {
+			result = append(result, val)
+		}
+		n, off = n.next, 0
+	}
+
+	return result
+}
+
+// forEach calls fn on the elements along with their indexes, from the head or from the tail, until
+// fn returns false.
+func (ql *quicklist) forEach(fromTail bool, fn func(idx int, val string) bool) {
+	if fromTail {
+		idx := ql.count - 1
+		for n := ql.tail; n != nil; n = n.prev {
+			for i := len(n.elems) - 1; i >= 0; i-- {
+				if !fn(idx, n.elems[i]) {
+					return
+				}
+				idx--
+			}
+		}
+		return
+	}
+
+	idx := 0
+	for n := ql.head; n != nil; n = n.next {
+		for _, val := range n.elems {
+			if !fn(idx, val) {
+				return
+			}
+			idx++
+		}
+	}
+}
+
+// deleteFunc removes the elements for which del returns true, calling it from the head or from the
+// tail. It returns the number of removed elements.
+func (ql *quicklist) deleteFunc(fromTail bool, del func(val string) bool) int {
+	removed := 0
+
+	n := ql.head
+	if fromTail {
+		n = ql.tail
+	}
+
+	for n != nil {
+		next := n.next
+		if fromTail {
+			next = n.prev
+		}
+
+		removed += ql.deleteInNode(n, fromTail, del)
+		n = next
+	}
+
+	return removed
+}
+
+// deleteInNode removes the elements of the node for which del returns true, and returns their number.
+func (ql *quicklist) deleteInNode(n *quicklistNode, fromTail bool, del func(val string) bool) int {
+	if fromTail {
+		slices.Reverse(n.elems)
+	}
+
+	kept := n.elems[:0]
+	for _, val := range n.elems {
+		if del(val) {
+			ql.count--
+			ql.bytes -= int64(len(val))
+			continue
+		}
+		kept = append(kept, val)
+	}
+	removed := len(n.elems) - len(kept)
+	clear(n.elems[len(kept):])
+	n.elems = kept
+
+	if fromTail {
+		slices.Reverse(n.elems)
+	}
+	if len(n.elems) == 0 {
+		ql.unlink(n)
+	}
+
+	return removed
+}
+
+// dropHead removes the given number of elements from the head of the list, whole nodes at once.
+func (ql *quicklist) dropHead(cnt int) {
+	for cnt > 0 && ql.head != nil {
+		n := ql.head
+		if cnt < len(n.elems) {
+			for range cnt {
+				ql.popHead()
+			}
+			return
+		}
+
+		cnt -= len(n.elems)
+		ql.count -= len(n.elems)
+		for _, val := range n.elems {
+			ql.bytes -= int64(len(val))
+		}
+		ql.unlink(n)
+	}
+}
+
+// dropTail removes the given number of elements from the tail of the list, whole nodes at once.
+func (ql *quicklist) dropTail(cnt int) {
+	for cnt > 0 && ql.tail != nil {
+		n := ql.tail
+		if cnt < len(n.elems) {
+			for range cnt {
+				ql.popTail()
+			}
+			return
+		}
+
+		cnt -= len(n.elems)
+		ql.count -= len(n.elems)
+		for _, val := range n.elems {
+			ql.bytes -= int64(len(val))
+		}
+		ql.unlink(n)
+	}
+}
+
+// clone returns a copy of the list.
+func (ql *quicklist) clone() *quicklist {
+	c := newQuicklist()
+	for n := ql.head; n != nil; n = n.next {
+		c.linkAfter(c.tail, &quicklistNode{elems: slices.Clone(n.elems)})
+	}
+	c.count, c.bytes = ql.count, ql.bytes
+
+	return c
+}
+
+// clear drops all the elements of the list, node by node.
+func (ql *quicklist) clear() {
+	for n := ql.head; n != nil; {
+		next := n.next
+		clear(n.elems)
+		n.prev, n.next, n.elems = nil, nil, nil
+		n = next
+	}
+
+	*ql = quicklist{}
+}
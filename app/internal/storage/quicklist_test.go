@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkQuicklist checks the links, the sizes and the counters of the nodes of the list, and that it
+// holds the elements of want in order.
+func checkQuicklist(t *testing.T, ql *quicklist, want []string) {
+	t.Helper()
+
+	var (
+		elems []string
+		nodes int
+		bytes int64
+		prev  *quicklistNode
+	)
+	for n := ql.head; n != nil; n = n.next {
+		if n.prev != prev {
+			t.Fatalf("node %d is not linked to its previous node", nodes)
+		}
+		if len(n.elems) == 0 || len(n.elems) > quicklistNodeSize {
+			t.Fatalf("node %d holds %d elements, want 1 to %d", nodes, len(n.elems), quicklistNodeSize)
+		}
+		for _, val := range n.elems {
+			bytes += int64(len(val))
+		}
+		elems = append(elems, n.elems...)
+		nodes++
+		prev = n
+	}
+
+	if ql.tail != prev {
+		t.Fatal("tail is not the last node")
+	}
+	if ql.count != len(elems) || ql.nodes != nodes || ql.bytes != bytes {
+		t.Fatalf("counters = %d elements, %d nodes, %d bytes, want %d, %d, %d",
+			ql.count, ql.nodes, ql.bytes, len(elems), nodes, bytes)
+	}
+	if !slices.Equal(elems, want) {
+		t.Fatalf("elements = %v, want %v", elems, want)
+	}
+
+	for i, val := range want {
+		if got := ql.index(i); got != val {
+			t.Fatalf("index(%d) = %q, want %q", i, got, val)
+		}
+	}
+}
+
+// seqQuicklist returns a list of the n elements "0" to "n-1" pushed at the tail, so that all its
+// nodes but the last are full, along with the elements.
+func seqQuicklist(n int) (*quicklist, []string) {
+	ql := newQuicklist()
+	elems := make([]string, n)
+	for i := range n {
+		elems[i] = strconv.Itoa(i)
+		ql.pushTail(elems[i])
+	}
+	return ql, elems
+}
+
+func TestQuicklistPush(t *testing.T) {
+	ql, elems := seqQuicklist(300)
+	checkQuicklist(t, ql, elems)
+	if ql.nodes != 3 {
+		t.Errorf("nodes = %d, want 3", ql.nodes)
+	}
+
+	head := newQuicklist()
+	for i := 299; i >= 0; i-- {
+		head.pushHead(strconv.Itoa(i))
+	}
+	checkQuicklist(t, head, elems)
+	if head.nodes != 3 {
+		t.Errorf("nodes = %d, want 3", head.nodes)
+	}
+}
+
+func TestQuicklistPopAcrossNodes(t *testing.T) {
+	ql, elems := seqQuicklist(300)
+
+	for range 129 {
+		if got := ql.popHead(); got != elems[0] {
+			t.Fatalf("popHead() = %q, want %q", got, elems[0])
+		}
+		elems = elems[1:]
+	}
+	checkQuicklist(t, ql, elems)
+
+	for range 45 {
+		if got := ql.popTail(); got != elems[len(elems)-1] {
+			t.Fatalf("popTail() = %q, want %q", got, elems[len(elems)-1])
+		}
+		elems = elems[:len(elems)-1]
+	}
+	checkQuicklist(t, ql, elems)
+	if ql.nodes != 1 {
+		t.Errorf("nodes = %d, want 1", ql.nodes)
+	}
+
+	for len(elems) > 0 {
+		ql.popHead()
+		elems = elems[1:]
+	}
+	checkQuicklist(t, ql, nil)
+}
+
+func TestQuicklistInsertAcrossNodes(t *testing.T) {
+	for _, idx := range []int{0, 1, 126, 127, 128, 129, 255, 256, 257, 299, 300} {
+		t.Run(strconv.Itoa(idx), func(t *testing.T) {
+			ql, elems := seqQuicklist(300)
+
+			// Inserting in a full node splits it
+			ql.insert(idx, "new")
+			elems = slices.Insert(elems, idx, "new")
+			checkQuicklist(t, ql, elems)
+
+			ql.insert(idx+1, "next")
+			elems = slices.Insert(elems, idx+1, "next")
+			checkQuicklist(t, ql, elems)
+		})
+	}
+}
+
+func TestQuicklistInsertSplitsFullNode(t *testing.T) {
+	ql, elems := seqQuicklist(quicklistNodeSize)
+	if ql.nodes != 1 {
+		t.Fatalf("nodes = %d, want 1", ql.nodes)
+	}
+
+	ql.insert(64, "new")
+	elems = slices.Insert(elems, 64, "new")
+	checkQuicklist(t, ql, elems)
+	if ql.nodes != 2 || len(ql.head.elems) != 64 || len(ql.tail.elems) != 65 {
+		t.Errorf("nodes = %d of %d and %d elements, want 2 of 64 and 65", ql.nodes, len(ql.head.elems), len(ql.tail.elems))
+	}
+}
+
+func TestQuicklistSetAcrossNodes(t *testing.T) {
+	ql, elems := seqQuicklist(300)
+	for _, idx := range []int{0, 127, 128, 255, 256, 299} {
+		ql.set(idx, "set-"+strconv.Itoa(idx))
+		elems[idx] = "set-" + strconv.Itoa(idx)
+	}
+	checkQuicklist(t, ql, elems)
+}
+
+func TestQuicklistDeleteAcrossNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		fromTail bool
+		del      func(idx int) bool
+	}{
+		{name: "node edges", del: func(idx int) bool { return idx >= 126 && idx <= 129 || idx == 256 }},
+		{name: "whole middle node", del: func(idx int) bool { return idx >= 128 && idx < 256 }},
+		{name: "all but the node edges", del: func(idx int) bool { return idx%128 != 0 && idx%128 != 127 }},
+		{name: "every other from the tail", fromTail: true, del: func(idx int) bool { return idx%2 == 0 }},
+		{name: "all", del: func(int) bool { return true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ql, elems := seqQuicklist(300)
+
+			var kept []string
+			for i, val := range elems {
+				if !tt.del(i) {
+					kept = append(kept, val)
+				}
+			}
+
+			removed := ql.deleteFunc(tt.fromTail, func(val string) bool {
+				i, _ := strconv.Atoi(val)
+				return tt.del(i)
+			})
+			if removed != len(elems)-len(kept) {
+				t.Errorf("deleteFunc() = %d, want %d", removed, len(elems)-len(kept))
+			}
+			checkQuicklist(t, ql, kept)
+		})
+	}
+}
+
+func TestQuicklistDropAcrossNodes(t *testing.T) {
+	for _, cnt := range []int{0, 1, 127, 128, 129, 172, 173, 256, 299, 300, 400} {
+		t.Run(strconv.Itoa(cnt), func(t *testing.T) {
+			ql, elems := seqQuicklist(300)
+			ql.dropHead(cnt)
+			checkQuicklist(t, ql, elems[min(cnt, len(elems)):])
+
+			ql, elems = seqQuicklist(300)
+			ql.dropTail(cnt)
+			checkQuicklist(t, ql, elems[:len(elems)-min(cnt, len(elems))])
+		})
+	}
+}
+
+func TestQuicklistRangeAcrossNodes(t *testing.T) {
+	ql, elems := seqQuicklist(300)
+	for _, r := range [][2]int{{0, 299}, {127, 128}, {100, 280}, {128, 255}, {256, 256}} {
+		got := ql.rangeElems(r[0], r[1])
+		want := make([]any, 0, r[1]-r[0]+1)
+		for _, val := range elems[r[0] : r[1]+1] {
+			want = append(want, val)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("rangeElems(%d, %d) = %v, want %v", r[0], r[1], got, want)
+		}
+	}
+}
+
+func TestQuicklistRandomOps(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	ql, elems := seqQuicklist(300)
+
+	for i := range 5000 {
+		val := "r" + strconv.Itoa(i)
+		switch op := rnd.IntN(6); {
+		case op == 0:
+			ql.pushHead(val)
+			elems = slices.Insert(elems, 0, val)
+		case op == 1:
+			ql.pushTail(val)
+			elems = append(elems, val)
+		case op == 2:
+			idx := rnd.IntN(len(elems) + 1)
+			ql.insert(idx, val)
+			elems = slices.Insert(elems, idx, val)
+		case op == 3 && len(elems) > 0:
+			idx := rnd.IntN(len(elems))
+			ql.set(idx, val)
+			elems[idx] = val
+		case op == 4 && len(elems) > 0:
+			ql.popHead()
+			elems = elems[1:]
+		case op == 5 && len(elems) > 0:
+			ql.popTail()
+			elems = elems[:len(elems)-1]
+		}
+
+		if i%500 == 0 {
+			checkQuicklist(t, ql, elems)
+		}
+	}
+	checkQuicklist(t, ql, elems)
+}
+
+func TestListCommandsAcrossNodes(t *testing.T) {
+	m := NewMem()
+	_, elems := seqQuicklist(300)
+	if _, err := m.Rpush("k", elems...); err != nil {
+		t.Fatalf("Rpush() error = %v", err)
+	}
+
+	// LINSERT around the node edges
+	for _, pivot := range []string{"127", "128", "256"} {
+		idx := slices.Index(elems, pivot)
+		if n, err := m.Linsert("k", true, pivot, "before-"+pivot); err != nil || n != len(elems)+1 {
+			t.Fatalf("Linsert() = %d, %v, want %d", n, err, len(elems)+1)
+		}
+		elems = slices.Insert(elems, idx, "before-"+pivot)
+		if n, err := m.Linsert("k", false, pivot, "after-"+pivot); err != nil || n != len(elems)+1 {
+			t.Fatalf("Linsert() = %d, %v, want %d", n, err, len(elems)+1)
+		}
+		elems = slices.Insert(elems, idx+2, "after-"+pivot)
+	}
+
+	// LSET around the node edges, counting from both ends
+	for _, idx := range []int{127, 128, 129, 256, -1, -128, -129} {
+		val := "set-" + strconv.Itoa(idx)
+		if err := m.Lset("k", idx, val); err != nil {
+			t.Fatalf("Lset(%d) error = %v", idx, err)
+		}
+		if idx < 0 {
+			idx += len(elems)
+		}
+		elems[idx] = val
+	}
+
+	// LREM some of the elements at the node edges
+	for _, val := range []string{"0", "126", "255", "298"} {
+		if n, err := m.Lrem("k", 0, val); err != nil || n != 1 {
+			t.Fatalf("Lrem(%q) = %d, %v, want 1", val, n, err)
+		}
+		elems = slices.DeleteFunc(elems, func(elem string) bool { return elem == val })
+	}
+
+	checkList := func() {
+		t.Helper()
+		got, err := m.Lrange("k", 0, -1)
+		if err != nil {
+			t.Fatalf("Lrange() error = %v", err)
+		}
+		want := make([]any, len(elems))
+		for i, val := range elems {
+			want[i] = val
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Lrange() = %v, want %v", got, want)
+		}
+		for _, idx := range []int{0, 127, 128, 256, len(elems) - 1} {
+			if idx >= len(elems) {
+				continue
+			}
+			if val, ok, err := m.Lindex("k", idx); err != nil || !ok || val != elems[idx] {
+				t.Fatalf("Lindex(%d) = %q, %v, %v, want %q", idx, val, ok, err, elems[idx])
+			}
+		}
+	}
+	checkList()
+
+	// LTRIM cutting through the first and the last nodes
+	if err := m.Ltrim("k", 1, -130); err != nil {
+		t.Fatalf("Ltrim() error = %v", err)
+	}
+	elems = elems[1 : len(elems)-129]
+	checkList()
+}
@@ -0,0 +1,173 @@
+package storage
+
+const (
+	// scanMaxIterFactor bounds the number of buckets visited by a single scan to count times this
+	// factor, so that a sparse table doesn't make the scan visit too many empty buckets.
+	scanMaxIterFactor = 10
+	// stringMatchMaxNesting is the number of stars of a pattern past which it fails to match, so that
+	// matching it can't exhaust the stack
+	stringMatchMaxNesting = 1000
+)
+
+// Scan iterates the keyspace from the given cursor, visiting the buckets until at least count keys
+// are gathered. It returns the gathered keys matching the pattern and the type, if they aren't empty,
+// along with the cursor to continue the iteration from, which is 0 once the iteration is complete.
+func (m *Mem) Scan(cursor uint64, count int, pattern, typ string) ([]string, uint64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		keys     []string
+		gathered int
+	)
+	for maxIter := count * scanMaxIterFactor; maxIter > 0 && gathered < count; maxIter-- {
+		cursor = m.mp.scan(cursor, func(e *dictEntry) {
+			gathered++
+			if m.isExpired(e.key) || !keyMatches(e, pattern, typ) {
+				return
+			}
+
+			keys = append(keys, e.key)
+		})
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return keys, cursor
+}
+
+// Keys returns all the keys matching the pattern.
+func (m *Mem) Keys(pattern string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var keys []string
+	m.mp.forEach(func(e *dictEntry) bool {
+		if !m.isExpired(e.key) && keyMatches(e, pattern, "") {
+			keys = append(keys, e.key)
+		}
+		return true
+	})
+
+	return keys
+}
+
+// keyMatches checks if the entry's key matches the glob-style pattern and its value is of the given type.
+// The empty pattern or type matches every entry.
+func keyMatches(e *dictEntry, pattern, typ string) bool {
+	if pattern != "" && pattern != "*" && !stringMatch(pattern, e.key) {
+		return false
+	}
+
+	return typ == "" || typeName(e.val) == typ
+}
+
+// stringMatch checks if the string matches the glob-style pattern. It supports "*" for any sequence,
+// "?" for any single character, "[...]" for a set or range of characters, negated by a leading "^",
+// and "\" to escape the special characters.
+func stringMatch(pattern, str string) bool {
+	var skipLonger bool
+	return stringMatchNested(pattern, str, 0, &skipLonger)
+}
+
+// stringMatchNested is stringMatch for the part of the pattern following nesting stars. Like Redis,
+// it fails past stringMatchMaxNesting stars, and it sets skipLonger once a star has tried all the
+// rest of the string in vain, since the stars before it can only leave it shorter strings, which
+// fail too. This keeps the patterns with many stars from taking exponential time.
+func stringMatchNested(pattern, str string, nesting int, skipLonger *bool) bool {
+	if nesting > stringMatchMaxNesting {
+		return false
+	}
+
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Consecutive stars are same as one
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+
+			// What follows the star matches at least one character
+			for i := range len(str) {
+				if stringMatchNested(pattern[1:], str[i:], nesting+1, skipLonger) {
+					return true
+				}
+				if *skipLonger {
+					return false
+				}
+			}
+			*skipLonger = true
+			return false
+
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+
+			var match bool
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					match = match || pattern[0] == str[0]
+
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					match = match || (str[0] >= start && str[0] <= end)
+					pattern = pattern[2:]
+
+				default:
+					match = match || pattern[0] == str[0]
+				}
+
+				pattern = pattern[1:]
+			}
+
+			if match == not {
+				return false
+			}
+			str = str[1:]
+
+			// Skip the closing bracket, if the set is terminated
+			if len(pattern) > 0 {
+				pattern = pattern[1:]
+			}
+
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+		}
+	}
+
+	return len(str) == 0
+}
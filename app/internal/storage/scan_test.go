@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		str     string
+		want    bool
+	}{
+		{name: "literal", pattern: "hello", str: "hello", want: true},
+		{name: "literal mismatch", pattern: "hello", str: "hell"},
+		{name: "star", pattern: "h*o", str: "hello", want: true},
+		{name: "empty star", pattern: "h*ello", str: "hello", want: true},
+		{name: "trailing star", pattern: "he*", str: "he", want: true},
+		{name: "consecutive stars", pattern: "h**l*o", str: "hello", want: true},
+		{name: "star mismatch", pattern: "h*x", str: "hello"},
+		{name: "question mark", pattern: "h?llo", str: "hallo", want: true},
+		{name: "question mark needs a character", pattern: "hello?", str: "hello"},
+		{name: "set", pattern: "h[ae]llo", str: "hello", want: true},
+		{name: "negated set", pattern: "h[^e]llo", str: "hello"},
+		{name: "range", pattern: "h[a-f]llo", str: "hello", want: true},
+		{name: "reversed range", pattern: "h[f-a]llo", str: "hello", want: true},
+		{name: "escaped star", pattern: `h\*o`, str: "h*o", want: true},
+		{name: "escaped star mismatch", pattern: `h\*o`, str: "hello"},
+		{name: "backtracking", pattern: "*a*b", str: "xaxaxb", want: true},
+		{name: "star before a set", pattern: "*[0-9]", str: "key:12", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stringMatch(tt.pattern, tt.str); got != tt.want {
+				t.Errorf("stringMatch(%q, %q) = %v, want %v", tt.pattern, tt.str, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringMatchManyStars(t *testing.T) {
+	// Without skipping the longer matches once a star fails, these take exponential time
+	long := strings.Repeat("a", 10000)
+	for _, pattern := range []string{
+		"*a*a*a*a*a*a*b",
+		strings.Repeat("*a", 30) + "b",
+		strings.Repeat("a*", 30) + "b",
+		strings.Repeat("*?", 30) + "b",
+	} {
+		if stringMatch(pattern, long) {
+			t.Errorf("stringMatch(%q) = true, want false", pattern)
+		}
+	}
+
+	if !stringMatch(strings.Repeat("*a", 30)+"*", long) {
+		t.Error("stringMatch() of the matching stars = false, want true")
+	}
+}
+
+func TestStringMatchNestingLimit(t *testing.T) {
+	str := strings.Repeat("a", stringMatchMaxNesting+1)
+
+	if !stringMatch(strings.Repeat("*a", stringMatchMaxNesting), str) {
+		t.Errorf("stringMatch() of %d stars = false, want true", stringMatchMaxNesting)
+	}
+	// The match fails past the limit, even though the string matches
+	if stringMatch(strings.Repeat("*a", stringMatchMaxNesting+1), str) {
+		t.Errorf("stringMatch() of %d stars = true, want false", stringMatchMaxNesting+1)
+	}
+}
@@ -0,0 +1,423 @@
+package storage
+
+import (
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+
+	"gokv/app/internal/errors"
+)
+
+const (
+	// setMaxIntsetEntries is the number of members up to which a set of integers is stored as an intset
+	setMaxIntsetEntries = 512
+	// maxRandRepeats is the maximum number of random members picked with repetitions, the reply
+	// holding all of them whatever the size of the set
+	maxRandRepeats = 1 << 24
+)
+
+// set is the set value. A small set of integers is stored as a sorted list of them, which is
+// binary searched, like the "intset" encoding of Redis. It's converted to a map once it has more
+// than setMaxIntsetEntries members or a member which isn't an integer, and it's never converted
+// back. The converted set keeps its members in a list too, so that random members are picked
+// uniformly in constant time.
+type set struct {
+	// ints holds the members in ascending order, while the set is an intset
+	ints []int64
+	// members holds the members in no particular order, once the set is converted
+	members []string
+	// index maps the members with their position in members, once the set is converted
+	index map[string]int
+	// bytes is the total length of the members once the set is converted, to estimate the memory used
+	bytes int64
+}
+
+func newSet() *set {
+	return &set{}
+}
+
+// Len returns the number of members of the set.
+func (s *set) Len() int {
+	if s.index != nil {
+		return len(s.members)
+	}
+	return len(s.ints)
+}
+
+// isIntset returns whether the set is stored as a sorted list of integers.
+func (s *set) isIntset() bool {
+	return s.index == nil
+}
+
+// contains checks if the member is in the set.
+func (s *set) contains(member string) bool {
+	if s.index != nil {
+		_, ok := s.index[member]
+		return ok
+	}
+
+	n, ok := parseStrictInt(member)
+	if !ok {
+		return false
+	}
+	_, found := slices.BinarySearch(s.ints, n)
+	return found
+}
+
+// add adds the member to the set. It returns true if the member wasn't in the set.
+func (s *set) add(member string) bool {
+	if s.index == nil {
+		n, ok := parseStrictInt(member)
+		if ok {
+			i, found := slices.BinarySearch(s.ints, n)
+			if found {
+				return false
+			}
+			if len(s.ints) < setMaxIntsetEntries {
+				s.ints = slices.Insert(s.ints, i, n)
+				return true
+			}
+		}
+		s.convert()
+	}
+
+	if _, ok := s.index[member]; ok {
+		return false
+	}
+
+	s.index[member] = len(s.members)
+	s.members = append(s.members, member)
+	s.bytes += int64(len(member))
+
+	return true
+}
+
+// remove removes the member from the set. It returns true if the member was in the set.
+func (s *set) remove(member string) bool {
+	if s.index == nil {
+		n, ok := parseStrictInt(member)
+		if !ok {
+			return false
+		}
+		i, found := slices.BinarySearch(s.ints, n)
+		if found {
+			s.ints = slices.Delete(s.ints, i, i+1)
+		}
+		return found
+	}
+
+	i, ok := s.index[member]
+	if !ok {
+		return false
+	}
+
+	// Fill the hole with the last member
+	last := len(s.members) - 1
+	s.members[i] = s.members[last]
+	s.index[s.members[i]] = i
+	s.members[last] = ""
+	s.members = s.members[:last]
+	delete(s.index, member)
+	s.bytes -= int64(len(member))
+
+	return true
+}
+
+// at returns the member at the position, which is between 0 and the number of members.
+func (s *set) at(i int) string {
+	if s.index != nil {
+		return s.members[i]
+	}
+	return strconv.FormatInt(s.ints[i], 10)
+}
+
+// random returns a random member of the set, which must not be empty.
+func (s *set) random() string {
+	return s.at(rand.IntN(s.Len()))
+}
+
+// sample returns count distinct random members of the set, which must have more than count members.
+func (s *set) sample(count int) []any {
+	n := s.Len()
+	picked := make([]any, 0, count)
+
+	// Shuffle the positions if a good portion of them is picked, and retry the duplicates otherwise
+	if count*3 > n {
+		for _, i := range rand.Perm(n)[:count] {
+			picked = append(picked, s.at(i))
+		}
+		return picked
+	}
+
+	seen := make(map[int]struct{}, count)
+	for len(picked) < count {
+		i := rand.IntN(n)
+		if _, ok := seen[i]; ok {
+			continue
+		}
+		seen[i] = struct{}{}
+		picked = append(picked, s.at(i))
+	}
+
+	return picked
+}
+
+// forEach calls fn on the members of the set, until fn returns false.
+func (s *set) forEach(fn func(member string) bool) {
+	for i := range s.Len() {
+		if !fn(s.at(i)) {
+			return
+		}
+	}
+}
+
+// elems returns all the members of the set.
+func (s *set) elems() []any {
+	elems := make([]any, 0, s.Len())
+	s.forEach(func(member string) bool {
+		elems = append(elems, member)
+		return true
+	})
+	return elems
+}
+
+// convert converts the intset to a map.
+func (s *set) convert() {
+	s.members = make([]string, 0, len(s.ints))
+	s.index = make(map[string]int, len(s.ints))
+	for _, n := range s.ints {
+		member := strconv.FormatInt(n, 10)
+		s.index[member] = len(s.members)
+		s.members = append(s.members, member)
+		s.bytes += int64(len(member))
+	}
+	s.ints = nil
+}
+
+// clone returns a copy of the set.
+func (s *set) clone() *set {
+	return &set{
+		ints:    slices.Clone(s.ints),
+		members: slices.Clone(s.members),
+		index:   maps.Clone(s.index),
+		bytes:   s.bytes,
+	}
+}
+
+// clear drops all the members of the set.
+func (s *set) clear() {
+	clear(s.members)
+	clear(s.index)
+	*s = set{}
+}
+
+// storeSet stores the set in the key, or removes the key if the set is empty. Caller must hold the
+// write lock.
+func (m *Mem) storeSet(key string, s *set) {
+	if s.Len() == 0 {
+		m.deleteKey(key)
+	} else {
+		m.mp.set(key, s)
+	}
+}
+
+// SAdd adds the members to the set of the key, creating the key if it doesn't exist. It returns the
+// number of members added.
+func (m *Mem) SAdd(key string, members ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok, err := lookupValue[*set](m, key, true)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		s = newSet()
+	}
+
+	added := 0
+	for _, member := range members {
+		if s.add(member) {
+			added++
+		}
+	}
+	m.mp.set(key, s)
+
+	return added, nil
+}
+
+// SRem removes the members from the set of the key, removing the key if no member is left. It
+// returns the number of members removed.
+func (m *Mem) SRem(key string, members ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok, err := lookupValue[*set](m, key, true)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if s.remove(member) {
+			removed++
+		}
+	}
+	m.storeSet(key, s)
+
+	return removed, nil
+}
+
+// SMembers returns the members of the set of the key.
+func (m *Mem) SMembers(key string) ([]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok, err := lookupValue[*set](m, key, false)
+	if err != nil || !ok {
+		return []any{}, err
+	}
+
+	return s.elems(), nil
+}
+
+// SIsMember returns whether the member is in the set of the key.
+func (m *Mem) SIsMember(key, member string) (bool, error) {
+	found, err := m.SMIsMember(key, member)
+	if err != nil {
+		return false, err
+	}
+
+	return found[0], nil
+}
+
+// SMIsMember returns whether each of the members is in the set of the key.
+func (m *Mem) SMIsMember(key string, members ...string) ([]bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok, err := lookupValue[*set](m, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]bool, len(members))
+	if ok {
+		for i, member := range members {
+			found[i] = s.contains(member)
+		}
+	}
+
+	return found, nil
+}
+
+// SCard returns the number of members of the set of the key.
+func (m *Mem) SCard(key string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok, err := lookupValue[*set](m, key, false)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return s.Len(), nil
+}
+
+// SPop removes and returns up to count random members of the set of the key, removing the key if
+// no member is left.
+func (m *Mem) SPop(key string, count int) ([]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok, err := lookupValue[*set](m, key, true)
+	if err != nil || !ok || count == 0 {
+		return []any{}, err
+	}
+
+	if count >= s.Len() {
+		popped := s.elems()
+		m.deleteKey(key)
+		return popped, nil
+	}
+
+	popped := make([]any, count)
+	for i := range popped {
+		member := s.random()
+		s.remove(member)
+		popped[i] = member
+	}
+	m.mp.set(key, s)
+
+	return popped, nil
+}
+
+// SRandMember returns up to count distinct random members of the set of the key. If count is
+// negative, it returns exactly -count random members, which may repeat, up to maxRandRepeats.
+func (m *Mem) SRandMember(key string, count int) ([]any, error) {
+	if count < -maxRandRepeats {
+		return nil, errors.ErrValueOutOfRange(-maxRandRepeats, math.MaxInt)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok, err := lookupValue[*set](m, key, false)
+	if err != nil || !ok || count == 0 {
+		return []any{}, err
+	}
+
+	if count < 0 {
+		// The count is up to the client, so the members are only allocated as they're picked
+		picked := make([]any, 0, min(-count, s.Len()))
+		for range -count {
+			picked = append(picked, s.random())
+		}
+		return picked, nil
+	}
+
+	if count >= s.Len() {
+		return s.elems(), nil
+	}
+
+	return s.sample(count), nil
+}
+
+// SMove moves the member from the set of src key to the set of dst key, creating dst key if it
+// doesn't exist. It returns whether the member was moved, which it's not if it isn't in src set.
+func (m *Mem) SMove(src, dst, member string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The missing source moves nothing, whatever the type of the destination
+	srcSet, ok, err := lookupValue[*set](m, src, true)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// Check the type of the destination before modifying the source
+	dstSet, dstOk, err := lookupValue[*set](m, dst, true)
+	if err != nil {
+		return false, err
+	}
+
+	if !srcSet.contains(member) {
+		return false, nil
+	}
+	if src == dst {
+		return true, nil
+	}
+
+	srcSet.remove(member)
+	m.storeSet(src, srcSet)
+
+	if !dstOk {
+		dstSet = newSet()
+	}
+	dstSet.add(member)
+	m.mp.set(dst, dstSet)
+
+	return true, nil
+}
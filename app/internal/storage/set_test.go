@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"gokv/app/internal/errors"
+)
+
+func TestSRandMember(t *testing.T) {
+	members := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name     string
+		count    int
+		wantLen  int
+		distinct bool
+		wantErr  bool
+	}{
+		{name: "zero", count: 0, wantLen: 0},
+		{name: "fewer than the set", count: 3, wantLen: 3, distinct: true},
+		{name: "whole set", count: 5, wantLen: 5, distinct: true},
+		{name: "max count", count: math.MaxInt, wantLen: 5, distinct: true},
+		{name: "repeated", count: -12, wantLen: 12},
+		{name: "past max repeats", count: -maxRandRepeats - 1, wantErr: true},
+		{name: "min count", count: math.MinInt, wantErr: true},
+		{name: "negated max count", count: -math.MaxInt, wantErr: true},
+	}
+
+	m := NewMem()
+	if _, err := m.SAdd("s", members...); err != nil {
+		t.Fatalf("SAdd() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picked, err := m.SRandMember("s", tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SRandMember() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(picked) != tt.wantLen {
+				t.Fatalf("SRandMember() returned %d members, want %d", len(picked), tt.wantLen)
+			}
+
+			seen := make(map[any]bool)
+			for _, member := range picked {
+				if !slices.Contains(members, member.(string)) {
+					t.Fatalf("SRandMember() returned %q, which isn't in the set", member)
+				}
+				if tt.distinct {
+					if seen[member] {
+						t.Fatalf("SRandMember() returned %q twice", member)
+					}
+					seen[member] = true
+				}
+			}
+		})
+	}
+}
+
+func TestSRandMemberMissingKey(t *testing.T) {
+	for _, count := range []int{0, 1, -1, math.MaxInt} {
+		picked, err := NewMem().SRandMember("missing", count)
+		if err != nil || len(picked) != 0 {
+			t.Errorf("SRandMember(%d) = %v, %v, want no member", count, picked, err)
+		}
+	}
+}
+
+func TestSMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst string
+		member   string
+		want     bool
+		wantErr  error
+		wantSrc  []string
+		wantDst  []string
+	}{
+		{name: "to a new set", src: "s", dst: "new", member: "a", want: true, wantSrc: []string{"b"}, wantDst: []string{"a"}},
+		{name: "to an existing set", src: "s", dst: "t", member: "b", want: true, wantSrc: []string{"a"}, wantDst: []string{"b", "c"}},
+		{name: "member missing", src: "s", dst: "t", member: "x", wantSrc: []string{"a", "b"}, wantDst: []string{"c"}},
+		{name: "same set", src: "s", dst: "s", member: "a", want: true, wantSrc: []string{"a", "b"}, wantDst: []string{"a", "b"}},
+		{name: "missing source to wrong type", src: "missing", dst: "str", member: "a"},
+		{name: "wrong type destination", src: "s", dst: "str", member: "a", wantErr: errors.ErrWrongType, wantSrc: []string{"a", "b"}},
+		{name: "wrong type destination without the member", src: "s", dst: "str", member: "x", wantErr: errors.ErrWrongType, wantSrc: []string{"a", "b"}},
+		{name: "wrong type source", src: "str", dst: "s", member: "a", wantErr: errors.ErrWrongType, wantDst: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			m.SAdd("s", "a", "b")
+			m.SAdd("t", "c")
+			m.SetWithOpts("str", "v", SetOpts{})
+
+			got, err := m.SMove(tt.src, tt.dst, tt.member)
+			if err != tt.wantErr {
+				t.Fatalf("SMove() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SMove() = %v, want %v", got, tt.want)
+			}
+
+			for key, want := range map[string][]string{tt.src: tt.wantSrc, tt.dst: tt.wantDst} {
+				if want == nil {
+					continue
+				}
+				members, err := m.SMembers(key)
+				if err != nil {
+					t.Fatalf("SMembers(%s) error = %v", key, err)
+				}
+				var got []string
+				for _, member := range members {
+					got = append(got, member.(string))
+				}
+				if slices.Sort(got); !slices.Equal(got, want) {
+					t.Errorf("SMembers(%s) = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package storage
+
+import "slices"
+
+// SetOp is the set operation of "SINTER", "SUNION" and "SDIFF" commands.
+type SetOp int
+
+const (
+	SetOpInter SetOp = iota
+	SetOpUnion
+	SetOpDiff
+)
+
+// lookupSets returns the sets of the keys, nil for the missing ones. It returns ErrWrongType if any
+// key holds a value of another type. Caller must hold the lock, the write lock if write is true.
+func (m *Mem) lookupSets(keys []string, write bool) ([]*set, error) {
+	sets := make([]*set, len(keys))
+	for i, key := range keys {
+		s, _, err := lookupValue[*set](m, key, write)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = s
+	}
+
+	return sets, nil
+}
+
+// bySize returns the sets ordered from the smallest to the largest, the missing ones first, so that
+// an intersection checks the fewest members.
+func bySize(sets []*set) []*set {
+	sorted := slices.Clone(sets)
+	slices.SortFunc(sorted, func(a, b *set) int {
+		return setLen(a) - setLen(b)
+	})
+	return sorted
+}
+
+// setLen returns the number of members of the set, which is 0 if it's missing.
+func setLen(s *set) int {
+	if s == nil {
+		return 0
+	}
+	return s.Len()
+}
+
+// containedInAll checks if the member is in all the sets.
+func containedInAll(member string, sets []*set) bool {
+	for _, s := range sets {
+		if !s.contains(member) {
+			return false
+		}
+	}
+	return true
+}
+
+// applySetOp performs the set operation between the sets, the missing ones being empty, and returns
+// the resulting set.
+func applySetOp(op SetOp, sets []*set) *set {
+	result := newSet()
+
+	switch op {
+	case SetOpInter:
+		sets = bySize(sets)
+		if sets[0] == nil {
+			return result
+		}
+
+		sets[0].forEach(func(member string) bool {
+			if containedInAll(member, sets[1:]) {
+				result.add(member)
+			}
+			return true
+		})
+
+	case SetOpUnion:
+		for _, s := range sets {
+			if s == nil {
+				continue
+			}
+			s.forEach(func(member string) bool {
+				result.add(member)
+				return true
+			})
+		}
+
+	case SetOpDiff:
+		if sets[0] == nil {
+			return result
+		}
+
+		sets[0].forEach(func(member string) bool {
+			for _, s := range sets[1:] {
+				if s != nil && s.contains(member) {
+					return true
+				}
+			}
+			result.add(member)
+			return true
+		})
+	}
+
+	return result
+}
+
+// SetOp performs the set operation between the sets of the keys, the missing keys being empty sets,
+// and returns the members of the result.
+func (m *Mem) SetOp(op SetOp, keys ...string) ([]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sets, err := m.lookupSets(keys, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return applySetOp(op, sets).elems(), nil
+}
+
+// SetOpStore is like SetOp, but it stores the result in dst key, overwriting it, or removes dst key
+// if the result is empty. It returns the number of members of the result.
+func (m *Mem) SetOpStore(op SetOp, dst string, keys ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sets, err := m.lookupSets(keys, true)
+	if err != nil {
+		return 0, err
+	}
+
+	result := applySetOp(op, sets)
+	m.deleteKey(dst)
+	m.storeSet(dst, result)
+
+	return result.Len(), nil
+}
+
+// SInterCard returns the number of members of the intersection of the sets of the keys, counting up
+// to limit members if it's not 0.
+func (m *Mem) SInterCard(limit int, keys ...string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sets, err := m.lookupSets(keys, false)
+	if err != nil {
+		return 0, err
+	}
+
+	sets = bySize(sets)
+	if sets[0] == nil {
+		return 0, nil
+	}
+
+	cnt := 0
+	sets[0].forEach(func(member string) bool {
+		if containedInAll(member, sets[1:]) {
+			cnt++
+		}
+		return limit == 0 || cnt < limit
+	})
+
+	return cnt, nil
+}
@@ -0,0 +1,226 @@
+package storage
+
+import "math/rand/v2"
+
+const (
+	// skiplistMaxLevel is the maximum number of levels of a skiplist node, enough for 2^64 elements
+	skiplistMaxLevel = 32
+	// skiplistP is the probability of a skiplist node having one more level
+	skiplistP = 0.25
+)
+
+// skiplist orders the members of a sorted set by score, then lexicographically, like the skiplist
+// of Redis. Each link holds the number of nodes it skips over, so that the rank of a member is
+// computed while looking it up.
+type skiplist struct {
+	// header is the sentinel node linked to the first node of each level
+	header *skiplistNode
+	tail   *skiplistNode
+	length int
+	// level is the number of levels of the highest node
+	level int
+}
+
+type skiplistNode struct {
+	member   string
+	score    float64
+	backward *skiplistNode
+	level    []skiplistLevel
+}
+
+type skiplistLevel struct {
+	forward *skiplistNode
+	// span is the number of nodes the forward link moves ahead by
+	span int
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		header: &skiplistNode{level: make([]skiplistLevel, skiplistMaxLevel)},
+		level:  1,
+	}
+}
+
+// before checks if the node is ordered before the member with the score.
+func (n *skiplistNode) before(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+// randomLevel returns the number of levels of a new node, higher levels being exponentially less likely.
+func randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistP {
+		level++
+	}
+	return level
+}
+
+// insert inserts the member with the score, which must not be in the skiplist yet.
+func (zsl *skiplist) insert(score float64, member string) *skiplistNode {
+	var (
+		update [skiplistMaxLevel]*skiplistNode
+		rank   [skiplistMaxLevel]int
+	)
+
+	// Find the node after which the member goes on each level, and the rank of that node
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		if i != zsl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > zsl.level {
+		for i := zsl.level; i < level; i++ {
+			update[i] = zsl.header
+			update[i].level[i].span = zsl.length
+		}
+		zsl.level = level
+	}
+
+	x = &skiplistNode{
+		member: member,
+		score:  score,
+		level:  make([]skiplistLevel, level),
+	}
+	for i := range level {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+
+	// The links above the new node now skip over it too
+	for i := level; i < zsl.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != zsl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		zsl.tail = x
+	}
+	zsl.length++
+
+	return x
+}
+
+// findUpdate returns the node after which the member with the score is, or would be, on each level.
+func (zsl *skiplist) findUpdate(score float64, member string) [skiplistMaxLevel]*skiplistNode {
+	var update [skiplistMaxLevel]*skiplistNode
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	return update
+}
+
+// deleteNode unlinks the node, given the node preceding it on each level.
+func (zsl *skiplist) deleteNode(x *skiplistNode, update [skiplistMaxLevel]*skiplistNode) {
+	for i := range zsl.level {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		zsl.tail = x.backward
+	}
+
+	for zsl.level > 1 && zsl.header.level[zsl.level-1].forward == nil {
+		zsl.level--
+	}
+	zsl.length--
+}
+
+// delete removes the member with the score. It returns true if it was in the skiplist.
+func (zsl *skiplist) delete(score float64, member string) bool {
+	update := zsl.findUpdate(score, member)
+
+	x := update[0].level[0].forward
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+
+	zsl.deleteNode(x, update)
+	return true
+}
+
+// updateScore changes the score of the member, which must be in the skiplist with curScore. The node
+// is moved only if the new score changes its position.
+func (zsl *skiplist) updateScore(curScore float64, member string, newScore float64) *skiplistNode {
+	update := zsl.findUpdate(curScore, member)
+	x := update[0].level[0].forward
+
+	if (x.backward == nil || x.backward.score < newScore) &&
+		(x.level[0].forward == nil || x.level[0].forward.score > newScore) {
+		x.score = newScore
+		return x
+	}
+
+	zsl.deleteNode(x, update)
+	return zsl.insert(newScore, member)
+}
+
+// rank returns the 1-based rank of the member with the score, or 0 if it's not in the skiplist.
+func (zsl *skiplist) rank(score float64, member string) int {
+	rank := 0
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil &&
+			(x.level[i].forward.before(score, member) || (x.level[i].forward.score == score && x.level[i].forward.member == member)) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+
+		if x != zsl.header && x.member == member {
+			return rank
+		}
+	}
+
+	return 0
+}
+
+// byRank returns the node of the 1-based rank, or nil if it's out of range.
+func (zsl *skiplist) byRank(rank int) *skiplistNode {
+	if rank <= 0 {
+		return nil
+	}
+
+	traversed := 0
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+
+		if traversed == rank {
+			return x
+		}
+	}
+
+	return nil
+}
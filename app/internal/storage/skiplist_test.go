@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"cmp"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+type skiplistEntry struct {
+	member string
+	score  float64
+}
+
+// sortedEntries returns the members of the model ordered like in a skiplist.
+func sortedEntries(model map[string]float64) []skiplistEntry {
+	entries := make([]skiplistEntry, 0, len(model))
+	for member, score := range model {
+		entries = append(entries, skiplistEntry{member: member, score: score})
+	}
+	slices.SortFunc(entries, func(a, b skiplistEntry) int {
+		return cmp.Or(cmp.Compare(a.score, b.score), cmp.Compare(a.member, b.member))
+	})
+	return entries
+}
+
+// checkSkiplist checks the links and the spans of the skiplist, and that rank and byRank agree with
+// the members of the model ordered by score.
+func checkSkiplist(t *testing.T, zsl *skiplist, model map[string]float64) {
+	t.Helper()
+
+	entries := sortedEntries(model)
+	if zsl.length != len(entries) {
+		t.Fatalf("length = %d, want %d", zsl.length, len(entries))
+	}
+
+	// The level 0 holds all the nodes in order, linked backward
+	var prev *skiplistNode
+	x := zsl.header.level[0].forward
+	for i, e := range entries {
+		if x == nil || x.member != e.member || x.score != e.score {
+			t.Fatalf("node %d = %+v, want %+v", i, x, e)
+		}
+		if x.backward != prev {
+			t.Fatalf("node %d is not linked backward to its previous node", i)
+		}
+		prev, x = x, x.level[0].forward
+	}
+	if zsl.tail != prev {
+		t.Fatal("tail is not the last node")
+	}
+
+	// The span of each link is the distance between the ranks of the nodes it links
+	ranks := make(map[*skiplistNode]int, len(entries))
+	for x, rank := zsl.header.level[0].forward, 1; x != nil; x, rank = x.level[0].forward, rank+1 {
+		ranks[x] = rank
+	}
+	for i := range zsl.level {
+		for x := zsl.header; x.level[i].forward != nil; x = x.level[i].forward {
+			if want := ranks[x.level[i].forward] - ranks[x]; x.level[i].span != want {
+				t.Fatalf("span on level %d after rank %d = %d, want %d", i, ranks[x], x.level[i].span, want)
+			}
+		}
+	}
+
+	for i, e := range entries {
+		if rank := zsl.rank(e.score, e.member); rank != i+1 {
+			t.Fatalf("rank(%v, %s) = %d, want %d", e.score, e.member, rank, i+1)
+		}
+		if n := zsl.byRank(i + 1); n == nil || n.member != e.member || n.score != e.score {
+			t.Fatalf("byRank(%d) = %+v, want %+v", i+1, n, e)
+		}
+	}
+
+	if n := zsl.byRank(0); n != nil {
+		t.Errorf("byRank(0) = %+v, want nil", n)
+	}
+	if n := zsl.byRank(len(entries) + 1); n != nil {
+		t.Errorf("byRank(%d) = %+v, want nil", len(entries)+1, n)
+	}
+	if rank := zsl.rank(0, "missing"); rank != 0 {
+		t.Errorf("rank() of a missing member = %d, want 0", rank)
+	}
+}
+
+func TestSkiplistRandomOps(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+	zsl := newSkiplist()
+	model := make(map[string]float64)
+
+	// Few distinct scores, so that many members are ordered by name
+	randomScore := func() float64 {
+		return float64(rnd.IntN(200)-100) / 4
+	}
+
+	for i := range 5000 {
+		member := "m" + strconv.Itoa(i)
+		score := randomScore()
+		zsl.insert(score, member)
+		model[member] = score
+	}
+	checkSkiplist(t, zsl, model)
+
+	members := slices.Sorted(maps.Keys(model))
+	for i := range 20000 {
+		member := members[rnd.IntN(len(members))]
+		score, ok := model[member]
+
+		switch {
+		case !ok:
+			score = randomScore()
+			zsl.insert(score, member)
+			model[member] = score
+		case rnd.IntN(3) == 0:
+			if !zsl.delete(score, member) {
+				t.Fatalf("delete(%v, %s) = false, want true", score, member)
+			}
+			delete(model, member)
+		default:
+			newScore := score + float64(rnd.IntN(9)-4)/4
+			if n := zsl.updateScore(score, member, newScore); n.member != member || n.score != newScore {
+				t.Fatalf("updateScore() = %+v, want %s with %v", n, member, newScore)
+			}
+			model[member] = newScore
+		}
+
+		if i%2000 == 0 {
+			checkSkiplist(t, zsl, model)
+		}
+	}
+	checkSkiplist(t, zsl, model)
+
+	if zsl.delete(1, "missing") {
+		t.Error("delete() of a missing member = true, want false")
+	}
+	for member, score := range model {
+		zsl.delete(score, member)
+		delete(model, member)
+	}
+	checkSkiplist(t, zsl, model)
+	if zsl.level != 1 {
+		t.Errorf("level of the empty skiplist = %d, want 1", zsl.level)
+	}
+}
@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"gokv/app/internal/errors"
+)
+
+// maxStringSize is the maximum length of a string value in bytes
+const maxStringSize = 512 * 1024 * 1024
+
+const (
+	// longDoublePrec is the number of bits of the mantissa of the x87 long double, which Redis
+	// increments the floats with
+	longDoublePrec = 64
+	// longDoubleMaxExp and longDoubleMinExp bound the exponent of a long double, as returned by
+	// big.Float.MantExp, the smallest one being the one of the least denormal number
+	longDoubleMaxExp = 16384
+	longDoubleMinExp = -16444
+)
+
+// compactString returns the value to store for the string. The string which is the canonical
+// representation of a 64-bit integer is stored as the integer itself, so that it takes less memory
+// and doesn't need to be parsed when incremented. Otherwise, it's stored as bytes which can be
+// modified in place.
+func compactString(s string) any {
+	if n, ok := parseStrictInt(s); ok {
+		return n
+	}
+
+	return []byte(s)
+}
+
+// stringOf returns the copy of the stored string value.
+func stringOf(val any) string {
+	switch v := val.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+// bytesOf returns the stored string value as bytes which can be modified in place. The integer is
+// converted to its bytes.
+func bytesOf(val any) []byte {
+	if n, ok := val.(int64); ok {
+		return strconv.AppendInt(nil, n, 10)
+	}
+
+	return val.([]byte)
+}
+
+// parseStrictInt parses the string as a 64-bit integer only if it's its canonical representation,
+// i.e. without a sign for positive values, leading zeros or spaces.
+func parseStrictInt(s string) (int64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || strconv.FormatInt(n, 10) != s {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// SetOpts holds the options of setting the string value of a key.
+type SetOpts struct {
+	// NX sets the value only if the key doesn't exist
+	NX bool
+	// XX sets the value only if the key exists
+	XX bool
+	// Get requires the old value to be a string, as it's going to be returned
+	Get bool
+	// KeepTTL retains the time-to-live of the key
+	KeepTTL bool
+	// ExpireAt is the time at which the key expires, if it's not zero
+	ExpireAt time.Time
+}
+
+// SetWithOpts sets the string value of the key as per the options. It returns the old string value
+// of the key, if any (nil otherwise), and whether the value was set.
+func (m *Mem) SetWithOpts(key string, val string, opts SetOpts) (any, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, exists := m.lookupKeyWrite(key)
+	if opts.Get && exists && !isString(old) {
+		return nil, false, errors.ErrWrongType
+	}
+	if exists && isString(old) {
+		old = stringOf(old)
+	} else {
+		old = nil
+	}
+
+	if (opts.NX && exists) || (opts.XX && !exists) {
+		return old, false, nil
+	}
+
+	at, hasTTL := m.expires[key]
+	m.mp.set(key, compactString(val))
+	delete(m.expires, key)
+
+	if opts.KeepTTL && hasTTL {
+		m.expires[key] = at
+	} else if !opts.ExpireAt.IsZero() {
+		m.expires[key] = opts.ExpireAt
+	}
+
+	return old, true, nil
+}
+
+// GetEx returns the string value of the key and updates its time-to-live. If persist is true,
+// the time-to-live is removed, otherwise the key expires at expireAt, if it's not zero.
+func (m *Mem) GetEx(key string, expireAt time.Time, persist bool) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	val, ok := m.lookupKeyWrite(key)
+	if !ok {
+		return "", false, nil
+	}
+	if !isString(val) {
+		return "", false, errors.ErrWrongType
+	}
+
+	switch {
+	case persist:
+		delete(m.expires, key)
+	case expireAt.IsZero():
+	case !expireAt.After(time.Now()):
+		m.deleteKey(key)
+	default:
+		m.expires[key] = expireAt
+	}
+
+	return stringOf(val), true, nil
+}
+
+// GetDel returns the string value of the key and removes the key.
+func (m *Mem) GetDel(key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	val, ok := m.lookupKeyWrite(key)
+	if !ok {
+		return "", false, nil
+	}
+	if !isString(val) {
+		return "", false, errors.ErrWrongType
+	}
+
+	m.deleteKey(key)
+	return stringOf(val), true, nil
+}
+
+// IncrBy increments the integer value of the key by delta, treating the missing key as 0.
+// It returns the incremented value.
+func (m *Mem) IncrBy(key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var cur int64
+	if val, ok := m.lookupKeyWrite(key); ok {
+		switch v := val.(type) {
+		case int64:
+			cur = v
+		case []byte:
+			n, ok := parseStrictInt(string(v))
+			if !ok {
+				return 0, errors.ErrNotANumericValue
+			}
+			cur = n
+		default:
+			return 0, errors.ErrWrongType
+		}
+	}
+
+	if (delta > 0 && cur > math.MaxInt64-delta) || (delta < 0 && cur < math.MinInt64-delta) {
+		return 0, errors.ErrIncrOverflow
+	}
+
+	cur += delta
+	m.mp.set(key, cur)
+
+	return cur, nil
+}
+
+// IncrByFloat increments the numeric value of the key by delta, treating the missing key as 0.
+// Like Redis, the values are added as long doubles. It returns the incremented value formatted as
+// it's stored.
+func (m *Mem) IncrByFloat(key, delta string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur := newLongDouble()
+	if val, ok := m.lookupKeyWrite(key); ok {
+		switch v := val.(type) {
+		case int64:
+			cur.SetInt64(v)
+		case []byte:
+			f, ok := parseLongDouble(string(v))
+			if !ok {
+				return "", errors.ErrNotAValidFloat
+			}
+			cur = f
+		default:
+			return "", errors.ErrWrongType
+		}
+	}
+
+	incr, ok := parseLongDouble(delta)
+	if !ok {
+		return "", errors.ErrNotAValidFloat
+	}
+
+	formatted, ok := addLongDouble(cur, incr)
+	if !ok {
+		return "", errors.ErrIncrNaNOrInf
+	}
+	m.mp.set(key, []byte(formatted))
+
+	return formatted, nil
+}
+
+// newLongDouble returns a zero float with the precision of a long double.
+func newLongDouble() *big.Float {
+	return new(big.Float).SetPrec(longDoublePrec)
+}
+
+// parseLongDouble parses the decimal float with the precision of a long double. It returns false if
+// it's not a finite number in the range of a long double.
+func parseLongDouble(s string) (*big.Float, bool) {
+	f, _, err := newLongDouble().Parse(s, 10)
+	if err != nil || !inLongDoubleRange(f) {
+		return nil, false
+	}
+	return f, true
+}
+
+// inLongDoubleRange checks if the float is finite, and neither overflows nor underflows a long double.
+func inLongDoubleRange(f *big.Float) bool {
+	if f.IsInf() {
+		return false
+	}
+	if f.Sign() == 0 {
+		return true
+	}
+	exp := f.MantExp(nil)
+	return exp <= longDoubleMaxExp && exp >= longDoubleMinExp
+}
+
+// addLongDouble adds the floats as long doubles and formats the sum like Redis does, with 17 decimal
+// digits and no trailing zeros. It returns false if the sum overflows a long double.
+func addLongDouble(a, b *big.Float) (string, bool) {
+	sum := newLongDouble().Add(a, b)
+	if sum.Sign() != 0 && sum.MantExp(nil) > longDoubleMaxExp {
+		return "", false
+	}
+
+	s := sum.Text('f', 17)
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0", true
+	}
+
+	return s, true
+}
+
+// Append appends the value to the string value of the key, creating the key if it doesn't exist.
+// It returns the length of the string after the append.
+func (m *Mem) Append(key, val string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b []byte
+	if cur, ok := m.lookupKeyWrite(key); ok {
+		if !isString(cur) {
+			return 0, errors.ErrWrongType
+		}
+
+		b = bytesOf(cur)
+	}
+
+	if len(b)+len(val) > maxStringSize {
+		return 0, errors.ErrStringTooLong
+	}
+
+	b = append(b, val...)
+	m.mp.set(key, b)
+
+	return len(b), nil
+}
+
+// Strlen returns the length of the string value of the key.
+func (m *Mem) Strlen(key string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	val, ok := m.lookupKey(key)
+	if !ok {
+		return 0, nil
+	}
+
+	switch v := val.(type) {
+	case int64:
+		return len(strconv.FormatInt(v, 10)), nil
+	case []byte:
+		return len(v), nil
+	default:
+		return 0, errors.ErrWrongType
+	}
+}
+
+// GetRange returns the substring of the string value of the key between the start and end offsets,
+// both inclusive. The negative offsets are counted from the end of the string.
+func (m *Mem) GetRange(key string, start, end int) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	val, ok := m.lookupKey(key)
+	if !ok {
+		return "", nil
+	}
+	if !isString(val) {
+		return "", errors.ErrWrongType
+	}
+
+	b := bytesOf(val)
+
+	// Handle negative offsets
+	if start < 0 {
+		start = max(len(b)+start, 0)
+	}
+	if end < 0 {
+		end = max(len(b)+end, 0)
+	}
+	end = min(end, len(b)-1)
+
+	if start > end || len(b) == 0 {
+		return "", nil
+	}
+
+	return string(b[start : end+1]), nil
+}
+
+// SetRange overwrites the part of the string value of the key starting at the offset with the value,
+// padding the string with zero bytes if it's shorter than the offset. It returns the length of the
+// string after the modification.
+func (m *Mem) SetRange(key string, offset int, val string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur, ok := m.lookupKeyWrite(key)
+	if ok && !isString(cur) {
+		return 0, errors.ErrWrongType
+	}
+
+	var b []byte
+	if ok {
+		b = bytesOf(cur)
+	}
+
+	// Nothing to modify, so don't create the key
+	if len(val) == 0 {
+		return len(b), nil
+	}
+	// Compare without adding to the offset, which may be large enough to overflow
+	if offset > maxStringSize-len(val) {
+		return 0, errors.ErrStringTooLong
+	}
+
+	if newLen := offset + len(val); newLen > len(b) {
+		b = append(b, make([]byte, newLen-len(b))...)
+	}
+	copy(b[offset:], val)
+	m.mp.set(key, b)
+
+	return len(b), nil
+}
+
+// MGet returns the string values of the keys. The value is nil for the key which doesn't exist or
+// doesn't hold a string.
+func (m *Mem) MGet(keys ...string) []any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	vals := make([]any, len(keys))
+	for i, key := range keys {
+		if val, ok := m.lookupKey(key); ok && isString(val) {
+			vals[i] = stringOf(val)
+		}
+	}
+
+	return vals
+}
+
+// MSet sets the string values of the keys given as the key-value pairs, removing their time-to-live.
+// All the keys are set at once, so that no other client sees only some of them set. If nx is true,
+// none of the keys is set if any of them exists. It returns whether the keys were set.
+func (m *Mem) MSet(pairs []string, nx bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if nx {
+		for i := 0; i < len(pairs); i += 2 {
+			if _, ok := m.lookupKeyWrite(pairs[i]); ok {
+				return false
+			}
+		}
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		m.mp.set(pairs[i], compactString(pairs[i+1]))
+		delete(m.expires, pairs[i])
+	}
+
+	return true
+}
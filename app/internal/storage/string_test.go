@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"gokv/app/internal/errors"
+)
+
+func TestSetRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		offset  int
+		val     string
+		wantLen int
+		wantStr string
+		wantErr error
+	}{
+		{name: "overwrite", initial: "hello", offset: 1, val: "ip", wantLen: 5, wantStr: "hiplo"},
+		{name: "extend", initial: "hello", offset: 4, val: "ooo", wantLen: 7, wantStr: "hellooo"},
+		{name: "pad with zero bytes", initial: "ab", offset: 4, val: "c", wantLen: 5, wantStr: "ab\x00\x00c"},
+		{name: "create at offset 0", offset: 0, val: "abc", wantLen: 3, wantStr: "abc"},
+		{name: "empty value keeps missing key", offset: math.MaxInt, val: "", wantLen: 0},
+		{name: "empty value at large offset", initial: "abc", offset: math.MaxInt, val: "", wantLen: 3, wantStr: "abc"},
+		{name: "max offset overflows", initial: "abc", offset: math.MaxInt, val: "x", wantErr: errors.ErrStringTooLong, wantStr: "abc"},
+		{name: "offset past max size", offset: maxStringSize, val: "x", wantErr: errors.ErrStringTooLong},
+		{name: "value ends past max size", offset: maxStringSize - 1, val: "xy", wantErr: errors.ErrStringTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			if tt.initial != "" {
+				if _, err := m.SetRange("k", 0, tt.initial); err != nil {
+					t.Fatalf("SetRange() initial error = %v", err)
+				}
+			}
+
+			n, err := m.SetRange("k", tt.offset, tt.val)
+			if err != tt.wantErr {
+				t.Fatalf("SetRange() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && n != tt.wantLen {
+				t.Errorf("SetRange() = %d, want %d", n, tt.wantLen)
+			}
+
+			str, ok, err := m.Get("k")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if ok != (tt.wantStr != "") || str != tt.wantStr {
+				t.Errorf("Get() = %q, %v, want %q", str, ok, tt.wantStr)
+			}
+		})
+	}
+}
+
+func TestIncrByFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		deltas  []string
+		want    string
+		wantErr error
+	}{
+		{name: "missing key", deltas: []string{"1.5"}, want: "1.5"},
+		{name: "0.1 plus 0.2", initial: "0.1", deltas: []string{"0.2"}, want: "0.3"},
+		{name: "1.1 plus 2.2", initial: "1.1", deltas: []string{"2.2"}, want: "3.3"},
+		{name: "repeated increments", initial: "10.50", deltas: []string{"0.1", "-5"}, want: "5.6"},
+		{name: "exponent notation", initial: "5.0e3", deltas: []string{"2.0e2"}, want: "5200"},
+		{name: "integer value", initial: "10", deltas: []string{"-10"}, want: "0"},
+		{name: "negative zero", initial: "-0.5", deltas: []string{"0.5"}, want: "0"},
+		{name: "delta not a float", initial: "1", deltas: []string{"abc"}, wantErr: errors.ErrNotAValidFloat},
+		{name: "delta infinite", initial: "1", deltas: []string{"inf"}, wantErr: errors.ErrNotAValidFloat},
+		{name: "value not a float", initial: "1.5x", deltas: []string{"1"}, wantErr: errors.ErrNotAValidFloat},
+		{name: "overflow", initial: "1e4932", deltas: []string{"1e4932"}, wantErr: errors.ErrIncrNaNOrInf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMem()
+			if tt.initial != "" {
+				if _, err := m.SetRange("k", 0, tt.initial); err != nil {
+					t.Fatalf("SetRange() error = %v", err)
+				}
+			}
+
+			var got string
+			var err error
+			for _, delta := range tt.deltas {
+				if got, err = m.IncrByFloat("k", delta); err != nil {
+					break
+				}
+			}
+			if err != tt.wantErr {
+				t.Fatalf("IncrByFloat() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("IncrByFloat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package storage
+
+// ZRangeBy is the kind of range of the "ZRANGE" command.
+type ZRangeBy int
+
+const (
+	ZRangeByRank ZRangeBy = iota
+	ZRangeByScore
+	ZRangeByLex
+)
+
+// ScoreRange is a range of scores of a sorted set.
+type ScoreRange struct {
+	Min, Max float64
+	// MinEx and MaxEx exclude the bounds from the range
+	MinEx, MaxEx bool
+}
+
+// LexRange is a range of members of a sorted set whose members all have the same score.
+type LexRange struct {
+	Min, Max string
+	// MinEx and MaxEx exclude the bounds from the range
+	MinEx, MaxEx bool
+	// NoMin and NoMax leave the range unbounded on that side, ignoring Min or Max
+	NoMin, NoMax bool
+}
+
+// ZRangeOpts is the options of the "ZRANGE" command.
+type ZRangeOpts struct {
+	By ZRangeBy
+	// Rev orders the members from the highest score
+	Rev bool
+	// Start and Stop are the ranks of the range by rank, both inclusive, the negative ranks
+	// counting from the end
+	Start, Stop int
+	Score       ScoreRange
+	Lex         LexRange
+	// Offset is the number of members of the range by score or lex to skip
+	Offset int
+	// Count is the number of members of the range by score or lex to return, all of them if it's
+	// negative
+	Count int
+}
+
+// zslRange is a range of a skiplist, bounding the elements from both sides.
+type zslRange interface {
+	// empty checks if no element can be in the range
+	empty() bool
+	// gteMin checks if the element of the node isn't below the range
+	gteMin(n *skiplistNode) bool
+	// lteMax checks if the element of the node isn't above the range
+	lteMax(n *skiplistNode) bool
+}
+
+func (r ScoreRange) empty() bool {
+	return r.Min > r.Max || (r.Min == r.Max && (r.MinEx || r.MaxEx))
+}
+
+func (r ScoreRange) gteMin(n *skiplistNode) bool {
+	if r.MinEx {
+		return n.score > r.Min
+	}
+	return n.score >= r.Min
+}
+
+func (r ScoreRange) lteMax(n *skiplistNode) bool {
+	if r.MaxEx {
+		return n.score < r.Max
+	}
+	return n.score <= r.Max
+}
+
+func (r LexRange) empty() bool {
+	if r.NoMin || r.NoMax {
+		return false
+	}
+	return r.Min > r.Max || (r.Min == r.Max && (r.MinEx || r.MaxEx))
+}
+
+func (r LexRange) gteMin(n *skiplistNode) bool {
+	switch {
+	case r.NoMin:
+		return true
+	case r.MinEx:
+		return n.member > r.Min
+	default:
+		return n.member >= r.Min
+	}
+}
+
+func (r LexRange) lteMax(n *skiplistNode) bool {
+	switch {
+	case r.NoMax:
+		return true
+	case r.MaxEx:
+		return n.member < r.Max
+	default:
+		return n.member <= r.Max
+	}
+}
+
+// inRange checks if some elements of the skiplist may be in the range.
+func (zsl *skiplist) inRange(r zslRange) bool {
+	if r.empty() {
+		return false
+	}
+	if zsl.tail == nil || !r.gteMin(zsl.tail) {
+		return false
+	}
+	return r.lteMax(zsl.header.level[0].forward)
+}
+
+// firstInRange returns the first node in the range, or nil if there is none.
+func (zsl *skiplist) firstInRange(r zslRange) *skiplistNode {
+	if !zsl.inRange(r) {
+		return nil
+	}
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !r.gteMin(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+
+	// The range overlaps the skiplist, so there is a next node
+	x = x.level[0].forward
+	if !r.lteMax(x) {
+		return nil
+	}
+	return x
+}
+
+// lastInRange returns the last node in the range, or nil if there is none.
+func (zsl *skiplist) lastInRange(r zslRange) *skiplistNode {
+	if !zsl.inRange(r) {
+		return nil
+	}
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && r.lteMax(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+
+	if x == zsl.header || !r.gteMin(x) {
+		return nil
+	}
+	return x
+}
+
+// count returns the number of nodes in the range.
+func (zsl *skiplist) count(r zslRange) int {
+	first := zsl.firstInRange(r)
+	if first == nil {
+		return 0
+	}
+	last := zsl.lastInRange(r)
+
+	return zsl.rank(last.score, last.member) - zsl.rank(first.score, first.member) + 1
+}
+
+// rangeElems returns the members of the sorted set in the range, as per the options.
+func (z *zset) rangeElems(opts ZRangeOpts) []ScoredMember {
+	if opts.By == ZRangeByRank {
+		return z.rangeByRank(opts.Start, opts.Stop, opts.Rev)
+	}
+
+	var r zslRange = opts.Score
+	if opts.By == ZRangeByLex {
+		r = opts.Lex
+	}
+
+	var (
+		x      *skiplistNode
+		inside func(n *skiplistNode) bool
+	)
+	if opts.Rev {
+		x, inside = z.zsl.lastInRange(r), r.gteMin
+	} else {
+		x, inside = z.zsl.firstInRange(r), r.lteMax
+	}
+	if x == nil || opts.Offset < 0 || opts.Count == 0 {
+		return []ScoredMember{}
+	}
+
+	// Jump over the skipped members by rank
+	if opts.Offset > 0 {
+		rank := z.zsl.rank(x.score, x.member)
+		if opts.Rev {
+			rank -= opts.Offset
+		} else {
+			rank += opts.Offset
+		}
+		if x = z.zsl.byRank(rank); x == nil {
+			return []ScoredMember{}
+		}
+	}
+
+	elems := []ScoredMember{}
+	for x != nil && inside(x) && (opts.Count < 0 || len(elems) < opts.Count) {
+		elems = append(elems, ScoredMember{Member: x.member, Score: x.score})
+		if opts.Rev {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+
+	return elems
+}
+
+// rangeByRank returns the members of the sorted set between the start and stop ranks, both
+// inclusive, the negative ranks counting from the end. The ranks count from the highest score if
+// rev is true.
+func (z *zset) rangeByRank(start, stop int, rev bool) []ScoredMember {
+	n := z.Len()
+	if start < 0 {
+		start = max(start+n, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return []ScoredMember{}
+	}
+
+	var x *skiplistNode
+	if rev {
+		x = z.zsl.byRank(n - start)
+	} else {
+		x = z.zsl.byRank(start + 1)
+	}
+
+	elems := make([]ScoredMember, stop-start+1)
+	for i := range elems {
+		elems[i] = ScoredMember{Member: x.member, Score: x.score}
+		if rev {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+
+	return elems
+}
+
+// ZRange returns the members of the sorted set of the key in the range, as per the options.
+func (m *Mem) ZRange(key string, opts ZRangeOpts) ([]ScoredMember, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	z, ok, err := lookupValue[*zset](m, key, false)
+	if err != nil || !ok {
+		return []ScoredMember{}, err
+	}
+
+	return z.rangeElems(opts), nil
+}
+
+// ZRangeStore stores the members of the sorted set of src key in the range, as per the options, in
+// dst key, overwriting it, or removes dst key if the range is empty. It returns the number of
+// members stored.
+func (m *Mem) ZRangeStore(dst, src string, opts ZRangeOpts) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	z, ok, err := lookupValue[*zset](m, src, true)
+	if err != nil {
+		return 0, err
+	}
+
+	result := newZset()
+	if ok {
+		for _, e := range z.rangeElems(opts) {
+			result.set(e.Member, e.Score)
+		}
+	}
+	m.deleteKey(dst)
+	m.storeZset(dst, result)
+
+	return result.Len(), nil
+}
+
+// ZCount returns the number of members of the sorted set of the key in the range of scores.
+func (m *Mem) ZCount(key string, r ScoreRange) (int, error) {
+	return m.zcount(key, r)
+}
+
+// ZLexCount returns the number of members of the sorted set of the key in the range of members.
+func (m *Mem) ZLexCount(key string, r LexRange) (int, error) {
+	return m.zcount(key, r)
+}
+
+// zcount returns the number of members of the sorted set of the key in the range.
+func (m *Mem) zcount(key string, r zslRange) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	z, ok, err := lookupValue[*zset](m, key, false)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return z.zsl.count(r), nil
+}
@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"maps"
+	"math"
+
+	"gokv/app/internal/errors"
+)
+
+// ScoredMember is a member of a sorted set along with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// ZAddOpts is the options of the "ZADD" command.
+type ZAddOpts struct {
+	// NX only adds the new members, without updating the existing ones
+	NX bool
+	// XX only updates the existing members, without adding new ones
+	XX bool
+	// GT only updates the existing members if the new score is greater than the current one
+	GT bool
+	// LT only updates the existing members if the new score is less than the current one
+	LT bool
+}
+
+// zaddOutcome is the outcome of adding a member to a sorted set.
+type zaddOutcome int
+
+const (
+	// zaddNop is the outcome of the options preventing the member to be added or updated
+	zaddNop zaddOutcome = iota
+	zaddAdded
+	zaddUpdated
+	// zaddUnchanged is the outcome of the member already having the score
+	zaddUnchanged
+)
+
+// zset is the sorted set value. Its members are mapped with their scores, and also kept in a
+// skiplist ordered by score, like the "skiplist" encoding of Redis.
+type zset struct {
+	dict map[string]float64
+	zsl  *skiplist
+	// bytes is the total length of the members, to estimate the memory used
+	bytes int64
+}
+
+func newZset() *zset {
+	return &zset{
+		dict: make(map[string]float64),
+		zsl:  newSkiplist(),
+	}
+}
+
+// Len returns the number of members of the sorted set.
+func (z *zset) Len() int {
+	return len(z.dict)
+}
+
+// score returns the score of the member.
+func (z *zset) score(member string) (float64, bool) {
+	score, ok := z.dict[member]
+	return score, ok
+}
+
+// set sets the score of the member, adding it if it's missing.
+func (z *zset) set(member string, score float64) {
+	cur, ok := z.dict[member]
+	switch {
+	case !ok:
+		z.zsl.insert(score, member)
+		z.dict[member] = score
+		z.bytes += int64(len(member))
+	case cur != score:
+		z.zsl.updateScore(cur, member, score)
+		z.dict[member] = score
+	}
+}
+
+// add adds the member with the score, or updates its score, as per the options. If incr is true, the
+// score is added to the current one. It returns the resulting score along with the outcome.
+func (z *zset) add(member string, score float64, opts ZAddOpts, incr bool) (float64, zaddOutcome, error) {
+	cur, ok := z.dict[member]
+	if !ok {
+		if opts.XX {
+			return 0, zaddNop, nil
+		}
+		z.set(member, score)
+		return score, zaddAdded, nil
+	}
+
+	if opts.NX {
+		return cur, zaddNop, nil
+	}
+	if incr {
+		score += cur
+		if math.IsNaN(score) {
+			return 0, zaddNop, errors.ErrScoreNaN
+		}
+	}
+	if (opts.GT && score <= cur) || (opts.LT && score >= cur) {
+		return cur, zaddNop, nil
+	}
+	if score == cur {
+		return cur, zaddUnchanged, nil
+	}
+
+	z.set(member, score)
+	return score, zaddUpdated, nil
+}
+
+// remove removes the member. It returns true if the member was in the sorted set.
+func (z *zset) remove(member string) bool {
+	score, ok := z.dict[member]
+	if !ok {
+		return false
+	}
+
+	delete(z.dict, member)
+	z.zsl.delete(score, member)
+	z.bytes -= int64(len(member))
+
+	return true
+}
+
+// rank returns the 0-based rank of the member, counting from the highest score if rev is true,
+// along with its score.
+func (z *zset) rank(member string, rev bool) (int, float64, bool) {
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, 0, false
+	}
+
+	rank := z.zsl.rank(score, member)
+	if rev {
+		return z.Len() - rank, score, true
+	}
+	return rank - 1, score, true
+}
+
+// clone returns a copy of the sorted set.
+func (z *zset) clone() *zset {
+	cloned := &zset{
+		dict:  maps.Clone(z.dict),
+		zsl:   newSkiplist(),
+		bytes: z.bytes,
+	}
+
+	// Insert from the tail, so that each node goes right after the header
+	for x := z.zsl.tail; x != nil; x = x.backward {
+		cloned.zsl.insert(x.score, x.member)
+	}
+
+	return cloned
+}
+
+// clear drops all the members of the sorted set.
+func (z *zset) clear() {
+	clear(z.dict)
+	*z = zset{}
+}
+
+// storeZset stores the sorted set in the key, or removes the key if the sorted set is empty.
+// Caller must hold the write lock.
+func (m *Mem) storeZset(key string, z *zset) {
+	if z.Len() == 0 {
+		m.deleteKey(key)
+	} else {
+		m.mp.set(key, z)
+	}
+}
+
+// ZAdd adds the members with their scores to the sorted set of the key, or updates their scores,
+// as per the options. The key is created if it doesn't exist, unless the XX option is set. It
+// returns the number of members added and updated.
+func (m *Mem) ZAdd(key string, opts ZAddOpts, elems ...ScoredMember) (int, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	z, ok, err := lookupValue[*zset](m, key, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !ok {
+		if opts.XX {
+			return 0, 0, nil
+		}
+		z = newZset()
+	}
+
+	var added, updated int
+	for _, e := range elems {
+		switch _, outcome, _ := z.add(e.Member, e.Score, opts, false); outcome {
+		case zaddAdded:
+			added++
+		case zaddUpdated:
+			updated++
+		}
+	}
+	m.storeZset(key, z)
+
+	return added, updated, nil
+}
+
+// ZIncrBy increments the score of the member of the sorted set of the key by delta, as per the
+// options, the missing member being added with delta as score. It returns the resulting score, and
+// false if the options prevented the increment.
+func (m *Mem) ZIncrBy(key string, opts ZAddOpts, delta float64, member string) (float64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	z, ok, err := lookupValue[*zset](m, key, true)
+	if err != nil {
+		return 0, false, err
+	}
+	if !ok {
+		if opts.XX {
+			return 0, false, nil
+		}
+		z = newZset()
+	}
+
+	score, outcome, err := z.add(member, delta, opts, true)
+	if err != nil {
+		return 0, false, err
+	}
+	m.storeZset(key, z)
+
+	return score, outcome != zaddNop, nil
+}
+
+// ZRem removes the members from the sorted set of the key, removing the key if no member is left.
+// It returns the number of members removed.
+func (m *Mem) ZRem(key string, members ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	z, ok, err := lookupValue[*zset](m, key, true)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if z.remove(member) {
+			removed++
+		}
+	}
+	m.storeZset(key, z)
+
+	return removed, nil
+}
+
+// ZScore returns the score of the member of the sorted set of the key.
+func (m *Mem) ZScore(key, member string) (float64, bool, error) {
+	scores, err := m.ZMScore(key, member)
+	if err != nil || scores[0] == nil {
+		return 0, false, err
+	}
+
+	return scores[0].(float64), true, nil
+}
+
+// ZMScore returns the scores of the members of the sorted set of the key, nil for the missing ones.
+func (m *Mem) ZMScore(key string, members ...string) ([]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	z, ok, err := lookupValue[*zset](m, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]any, len(members))
+	if !ok {
+		return scores, nil
+	}
+
+	for i, member := range members {
+		if score, ok := z.score(member); ok {
+			scores[i] = score
+		}
+	}
+
+	return scores, nil
+}
+
+// ZCard returns the number of members of the sorted set of the key.
+func (m *Mem) ZCard(key string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	z, ok, err := lookupValue[*zset](m, key, false)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	return z.Len(), nil
+}
+
+// ZRank returns the 0-based rank of the member of the sorted set of the key, counting from the
+// highest score if rev is true, along with its score.
+func (m *Mem) ZRank(key, member string, rev bool) (int, float64, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	z, ok, err := lookupValue[*zset](m, key, false)
+	if err != nil || !ok {
+		return 0, 0, false, err
+	}
+
+	rank, score, ok := z.rank(member, rev)
+	return rank, score, ok, nil
+}
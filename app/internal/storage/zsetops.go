@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"math"
+	"slices"
+
+	"gokv/app/internal/errors"
+)
+
+// ZAggregate is the way the scores of a member are combined by the "ZUNION" and "ZINTER" commands.
+type ZAggregate int
+
+const (
+	ZAggregateSum ZAggregate = iota
+	ZAggregateMin
+	ZAggregateMax
+)
+
+// ZSetOpOpts is the options of the set operations between sorted sets.
+type ZSetOpOpts struct {
+	// Weights multiply the scores of the members of each input, all of them being 1 if it's nil
+	Weights   []float64
+	Aggregate ZAggregate
+}
+
+// apply combines the current score of a member with another score of it.
+func (agg ZAggregate) apply(cur, score float64) float64 {
+	// The current score is kept if they're equal, even if they're zeros of opposite signs
+	switch agg {
+	case ZAggregateMin:
+		if score < cur {
+			return score
+		}
+		return cur
+	case ZAggregateMax:
+		if score > cur {
+			return score
+		}
+		return cur
+	default:
+		// The sum of opposite infinities is 0
+		if sum := cur + score; !math.IsNaN(sum) {
+			return sum
+		}
+		return 0
+	}
+}
+
+// zsetOpInput is an input of a set operation between sorted sets, which is either a sorted set or a
+// set whose members all score 1. Both are nil if the key is missing.
+type zsetOpInput struct {
+	z      *zset
+	s      *set
+	weight float64
+}
+
+// Len returns the number of members of the input.
+func (in zsetOpInput) Len() int {
+	switch {
+	case in.z != nil:
+		return in.z.Len()
+	case in.s != nil:
+		return in.s.Len()
+	default:
+		return 0
+	}
+}
+
+// score returns the weighted score of the member of the input.
+func (in zsetOpInput) score(member string) (float64, bool) {
+	switch {
+	case in.z != nil:
+		score, ok := in.z.score(member)
+		return in.weighted(score), ok
+	case in.s != nil:
+		return in.weighted(1), in.s.contains(member)
+	default:
+		return 0, false
+	}
+}
+
+// weighted returns the score multiplied by the weight of the input, 0 if an infinity is weighted 0.
+func (in zsetOpInput) weighted(score float64) float64 {
+	if score *= in.weight; !math.IsNaN(score) {
+		return score
+	}
+	return 0
+}
+
+// forEach calls fn with each member of the input along with its weighted score, until fn returns
+// false.
+func (in zsetOpInput) forEach(fn func(member string, score float64) bool) {
+	switch {
+	case in.z != nil:
+		for member, score := range in.z.dict {
+			if !fn(member, in.weighted(score)) {
+				return
+			}
+		}
+	case in.s != nil:
+		in.s.forEach(func(member string) bool {
+			return fn(member, in.weighted(1))
+		})
+	}
+}
+
+// lookupZsetOpInputs returns the inputs of the keys, weighted as per the weights if they're not nil.
+// It returns ErrWrongType if any key holds neither a sorted set nor a set. Caller must hold the lock,
+// the write lock if write is true.
+func (m *Mem) lookupZsetOpInputs(keys []string, weights []float64, write bool) ([]zsetOpInput, error) {
+	inputs := make([]zsetOpInput, len(keys))
+	for i, key := range keys {
+		val, _, err := lookupValue[any](m, key, write)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := val.(type) {
+		case nil:
+		case *zset:
+			inputs[i].z = v
+		case *set:
+			inputs[i].s = v
+		default:
+			return nil, errors.ErrWrongType
+		}
+
+		inputs[i].weight = 1
+		if weights != nil {
+			inputs[i].weight = weights[i]
+		}
+	}
+
+	return inputs, nil
+}
+
+// bySizeInputs returns the inputs ordered from the smallest to the largest, so that an intersection
+// checks the fewest members.
+func bySizeInputs(inputs []zsetOpInput) []zsetOpInput {
+	sorted := slices.Clone(inputs)
+	slices.SortStableFunc(sorted, func(a, b zsetOpInput) int {
+		return a.Len() - b.Len()
+	})
+	return sorted
+}
+
+// interScore returns the aggregated score of the member in all the inputs, starting from score. It
+// returns false if the member is missing from any input.
+func interScore(member string, score float64, inputs []zsetOpInput, agg ZAggregate) (float64, bool) {
+	for _, in := range inputs {
+		other, ok := in.score(member)
+		if !ok {
+			return 0, false
+		}
+		score = agg.apply(score, other)
+	}
+	return score, true
+}
+
+// applyZsetOp performs the set operation between the inputs, aggregating the scores of the members
+// as per agg, and returns the resulting sorted set.
+func applyZsetOp(op SetOp, inputs []zsetOpInput, agg ZAggregate) *zset {
+	scores := make(map[string]float64)
+
+	switch op {
+	case SetOpInter:
+		inputs = bySizeInputs(inputs)
+		inputs[0].forEach(func(member string, score float64) bool {
+			if score, ok := interScore(member, score, inputs[1:], agg); ok {
+				scores[member] = score
+			}
+			return true
+		})
+
+	case SetOpUnion:
+		for _, in := range inputs {
+			in.forEach(func(member string, score float64) bool {
+				if cur, ok := scores[member]; ok {
+					score = agg.apply(cur, score)
+				}
+				scores[member] = score
+				return true
+			})
+		}
+
+	case SetOpDiff:
+		inputs[0].forEach(func(member string, score float64) bool {
+			for _, in := range inputs[1:] {
+				if _, ok := in.score(member); ok {
+					return true
+				}
+			}
+			scores[member] = score
+			return true
+		})
+	}
+
+	result := newZset()
+	for member, score := range scores {
+		result.set(member, score)
+	}
+
+	return result
+}
+
+// ZSetOp performs the set operation between the sorted sets or sets of the keys, the missing keys
+// being empty and the members of the sets scoring 1, and returns the members of the result ordered
+// by score.
+func (m *Mem) ZSetOp(op SetOp, opts ZSetOpOpts, keys ...string) ([]ScoredMember, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inputs, err := m.lookupZsetOpInputs(keys, opts.Weights, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyZsetOp(op, inputs, opts.Aggregate).rangeByRank(0, -1, false), nil
+}
+
+// ZSetOpStore is like ZSetOp, but it stores the result in dst key, overwriting it, or removes dst
+// key if the result is empty. It returns the number of members of the result.
+func (m *Mem) ZSetOpStore(op SetOp, opts ZSetOpOpts, dst string, keys ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inputs, err := m.lookupZsetOpInputs(keys, opts.Weights, true)
+	if err != nil {
+		return 0, err
+	}
+
+	result := applyZsetOp(op, inputs, opts.Aggregate)
+	m.deleteKey(dst)
+	m.storeZset(dst, result)
+
+	return result.Len(), nil
+}
+
+// ZInterCard returns the number of members of the intersection of the sorted sets or sets of the
+// keys, counting up to limit members if it's not 0.
+func (m *Mem) ZInterCard(limit int, keys ...string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inputs, err := m.lookupZsetOpInputs(keys, nil, false)
+	if err != nil {
+		return 0, err
+	}
+
+	inputs = bySizeInputs(inputs)
+	cnt := 0
+	inputs[0].forEach(func(member string, _ float64) bool {
+		if _, ok := interScore(member, 0, inputs[1:], ZAggregateSum); ok {
+			cnt++
+		}
+		return limit == 0 || cnt < limit
+	})
+
+	return cnt, nil
+}
@@ -7,12 +7,28 @@ import (
 	"os"
 
 	"gokv/app/internal/server"
+	"gokv/app/internal/storage"
 )
 
 func main() {
 	port := flag.Int("port", 6379, "The port on which the server should start.")
+	databases := flag.Int("databases", 16, "The number of logical databases.")
+	maxMemory := flag.String("maxmemory", "0", "The memory limit, such as 100mb. There is no limit if it's 0.")
+	maxMemoryPolicy := flag.String("maxmemory-policy", "noeviction", "The policy of evicting the keys once the memory limit is reached.")
 	flag.Parse()
 
+	maxMemoryBytes, err := server.ParseMemory(*maxMemory)
+	if err != nil {
+		fmt.Println("Invalid maxmemory: ", err.Error())
+		os.Exit(1)
+	}
+
+	policy, err := storage.ParseEvictionPolicy(*maxMemoryPolicy)
+	if err != nil {
+		fmt.Println("Invalid maxmemory-policy: ", err.Error())
+		os.Exit(1)
+	}
+
 	l, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%v", *port))
 	if err != nil {
 		fmt.Println("Failed to bind to port 6379")
@@ -20,7 +36,11 @@ func main() {
 	}
 
 	// Create server instance
-	srv := server.NewServer()
+	srv := server.NewServer(server.Config{
+		Databases:       *databases,
+		MaxMemory:       maxMemoryBytes,
+		MaxMemoryPolicy: policy,
+	})
 
 	for {
 		conn, err := l.Accept()